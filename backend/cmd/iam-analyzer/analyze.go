@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/account"
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/cfn"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+	"github.com/Kuba0517/iam-analyzer/internal/pipeline"
+	"github.com/Kuba0517/iam-analyzer/internal/sarif"
+	"github.com/Kuba0517/iam-analyzer/internal/terraform"
+)
+
+type fileReport struct {
+	File        string             `json:"file"`
+	Error       string             `json:"error,omitempty"`
+	Score       *model.ScoreResult `json:"score,omitempty"`
+	Findings    []model.Finding    `json:"findings,omitempty"`
+	Suggestions []model.Patch      `json:"suggestions,omitempty"`
+}
+
+type thresholds struct {
+	failOn   string
+	maxScore int
+	maxRank  string
+}
+
+func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	failOn := fs.String("fail-on", "high", "fail when a finding has this severity or higher: low, medium, high or none")
+	maxScore := fs.Int("max-score", -1, "fail when the risk score is above this value (-1 disables)")
+	maxRank := fs.String("max-rank", "", "fail when the rank is worse than this one, A (best) to F")
+	configPath := fs.String("config", "", "analyzer rule configuration file")
+	catalogPath := fs.String("catalog", "", "action catalog file to use instead of the embedded one")
+	strict := fs.Bool("strict", false, "reject unknown, misspelled and duplicate keys")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	var opts []parser.Option
+	if *strict {
+		opts = append(opts, parser.WithStrict())
+	}
+
+	th := thresholds{failOn: strings.ToLower(*failOn), maxScore: *maxScore, maxRank: strings.ToUpper(*maxRank)}
+	if err := th.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return exitError
+	}
+	if err := setup(*catalogPath, *configPath); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	files, err := expandInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	reports := make([]fileReport, 0, len(files))
+	for _, path := range files {
+		reports = append(reports, analyzeFile(path, stdin, opts)...)
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+	case "sarif":
+		if err := writeSARIF(stdout, reports); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		for _, r := range reports {
+			if r.Error != "" {
+				fmt.Fprintf(stderr, "%s: %s\n", r.File, r.Error)
+			}
+		}
+	default:
+		writeText(stdout, reports)
+	}
+
+	code := exitOK
+	for _, r := range reports {
+		if r.Error != "" {
+			code = exitError
+			continue
+		}
+		if reason := th.exceeded(r); reason != "" {
+			fmt.Fprintf(stderr, "%s: %s\n", r.File, reason)
+			if code == exitOK {
+				code = exitThreshold
+			}
+		}
+	}
+	return code
+}
+
+// analyzeFile reports on a policy file, on each policy of a CloudFormation
+// template as "file#LogicalID", on each policy of a Terraform plan or .tf
+// file as "file#address", or on each entity of an account authorization
+// details dump as "file#type/name" and each of its own policy documents as
+// "file#type/name/policy".
+func analyzeFile(path string, stdin io.Reader, opts []parser.Option) []fileReport {
+	in, err := readInput(path, stdin)
+	if err != nil {
+		return []fileReport{{File: path, Error: err.Error()}}
+	}
+
+	if cfn.IsTemplate(in.data) {
+		tmpl, err := pipeline.AnalyzeTemplate(in.data, opts...)
+		if err != nil {
+			return []fileReport{{File: path, Error: err.Error()}}
+		}
+		reports := make([]fileReport, 0, len(tmpl.Policies))
+		for _, tp := range tmpl.Policies {
+			name := path + "#" + tp.LogicalID
+			if tp.PolicyName != "" {
+				name += "/" + tp.PolicyName
+			}
+			r := fileReport{File: name, Error: tp.Error}
+			if tp.Analysis != nil {
+				r.Score, r.Findings, r.Suggestions = &tp.Analysis.Score, tp.Analysis.Findings, tp.Analysis.Suggestions
+			}
+			reports = append(reports, r)
+		}
+		return reports
+	}
+
+	if account.IsDump(in.data) {
+		acct, err := pipeline.AnalyzeAccount(in.data, opts...)
+		if err != nil {
+			return []fileReport{{File: path, Error: err.Error()}}
+		}
+		reports := make([]fileReport, 0, len(acct.Entities))
+		for _, e := range acct.Entities {
+			name := path + "#" + e.Type + "/" + e.Name
+			var errs []string
+			for _, p := range e.Policies {
+				if p.Error != "" {
+					errs = append(errs, p.Name+": "+p.Error)
+				}
+			}
+			if e.Analysis != nil || len(errs) > 0 {
+				r := fileReport{File: name, Error: strings.Join(errs, "; ")}
+				if e.Analysis != nil {
+					r.Score, r.Findings = &e.Analysis.Score, e.Analysis.Findings
+				}
+				reports = append(reports, r)
+			}
+
+			// Each document is reported once: inline policies under the
+			// entity they are embedded in, managed policies under their own
+			// entry.
+			for _, p := range e.Policies {
+				if p.Analysis == nil || p.Via != "" || (p.Kind == "managed" && e.Type != "policy") {
+					continue
+				}
+				reports = append(reports, fileReport{
+					File:        name + "/" + p.Name,
+					Score:       &p.Analysis.Score,
+					Findings:    p.Analysis.Findings,
+					Suggestions: p.Analysis.Suggestions,
+				})
+			}
+		}
+		return reports
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".tf") || terraform.IsPlan(in.data) {
+		tf, err := pipeline.AnalyzeTerraform(in.data, path, opts...)
+		if err != nil {
+			return []fileReport{{File: path, Error: err.Error()}}
+		}
+		reports := make([]fileReport, 0, len(tf.Policies))
+		for _, tp := range tf.Policies {
+			name := path + "#" + tp.Address
+			if tp.PolicyName != "" {
+				name += "/" + tp.PolicyName
+			}
+			r := fileReport{File: name, Error: tp.Error}
+			if tp.Analysis != nil {
+				r.Score, r.Findings, r.Suggestions = &tp.Analysis.Score, tp.Analysis.Findings, tp.Analysis.Suggestions
+			}
+			reports = append(reports, r)
+		}
+		return reports
+	}
+
+	res, err := pipeline.Run(in.data, opts...)
+	if err != nil {
+		return []fileReport{{File: path, Error: err.Error()}}
+	}
+	return []fileReport{{
+		File:        path,
+		Score:       &res.Score,
+		Findings:    res.Findings,
+		Suggestions: res.Suggestions,
+	}}
+}
+
+func writeSARIF(w io.Writer, reports []fileReport) error {
+	artifacts := make([]sarif.Artifact, 0, len(reports))
+	for _, r := range reports {
+		if r.Error != "" {
+			continue
+		}
+		artifacts = append(artifacts, sarif.Artifact{URI: r.File, Findings: r.Findings})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarif.Export(artifacts))
+}
+
+func (t thresholds) validate() error {
+	if t.failOn != "none" && analyzer.SeverityRank(model.Severity(t.failOn)) == 0 {
+		return fmt.Errorf("unknown severity %q for -fail-on", t.failOn)
+	}
+	if t.maxRank != "" && (len(t.maxRank) != 1 || !strings.Contains("ABCDF", t.maxRank)) {
+		return fmt.Errorf("unknown rank %q for -max-rank", t.maxRank)
+	}
+	return nil
+}
+
+// exceeded returns why the report fails the thresholds, or "" if it passes.
+func (t thresholds) exceeded(r fileReport) string {
+	if t.failOn != "none" {
+		min := analyzer.SeverityRank(model.Severity(t.failOn))
+		n := 0
+		for _, f := range r.Findings {
+			if analyzer.SeverityRank(f.Severity) >= min {
+				n++
+			}
+		}
+		if n > 0 {
+			return fmt.Sprintf("%d finding(s) at or above %s", n, t.failOn)
+		}
+	}
+	if t.maxScore >= 0 && r.Score.Score > t.maxScore {
+		return fmt.Sprintf("score %d is above %d", r.Score.Score, t.maxScore)
+	}
+	if t.maxRank != "" && r.Score.Rank > t.maxRank {
+		return fmt.Sprintf("rank %s is worse than %s", r.Score.Rank, t.maxRank)
+	}
+	return ""
+}
+
+func writeText(w io.Writer, reports []fileReport) {
+	counts := make(map[model.Severity]int)
+	for _, r := range reports {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s: error: %s\n", r.File, r.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s: score %d, rank %s\n", r.File, r.Score.Score, r.Score.Rank)
+		for _, f := range r.Findings {
+			counts[f.Severity]++
+			fmt.Fprintf(w, "  %-6s  %s: %s (%s)\n", f.Severity, f.RuleID, f.Title, where(f))
+			if f.Evidence != "" {
+				fmt.Fprintf(w, "          %s\n", f.Evidence)
+			}
+		}
+		for _, s := range r.Suggestions {
+			fmt.Fprintf(w, "  fix     %s: %s\n", s.ID, s.Title)
+		}
+	}
+
+	total := counts[model.SeverityHigh] + counts[model.SeverityMedium] + counts[model.SeverityLow]
+	fmt.Fprintf(w, "\n%d file(s), %d finding(s): %d high, %d medium, %d low\n",
+		len(reports), total, counts[model.SeverityHigh], counts[model.SeverityMedium], counts[model.SeverityLow])
+}
+
+// where prefers source lines over statement indices.
+func where(f model.Finding) string {
+	if len(f.Locations) == 0 {
+		return plural("statement", len(f.OriginalIndices)) + " " + joinInts(f.OriginalIndices)
+	}
+	lines := make([]int, len(f.Locations))
+	for i, l := range f.Locations {
+		lines[i] = l.Start.Line
+	}
+	return plural("line", len(lines)) + " " + joinInts(lines)
+}
+
+func plural(word string, n int) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func joinInts(ints []int) string {
+	parts := make([]string, len(ints))
+	for i, n := range ints {
+		parts[i] = fmt.Sprint(n)
+	}
+	return strings.Join(parts, ", ")
+}
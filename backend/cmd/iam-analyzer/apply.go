@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+	"github.com/Kuba0517/iam-analyzer/internal/pipeline"
+)
+
+type patchFlags struct {
+	patches     string
+	configPath  string
+	catalogPath string
+}
+
+func (p *patchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.patches, "patch", "", "comma-separated patch IDs to apply (default: all suggestions)")
+	fs.StringVar(&p.configPath, "config", "", "analyzer rule configuration file")
+	fs.StringVar(&p.catalogPath, "catalog", "", "action catalog file to use instead of the embedded one")
+}
+
+// simplify returns the normalized policy and the result of applying the
+// selected suggestions to it.
+func (p *patchFlags) simplify(in input) (*model.Policy, *model.Policy, error) {
+	resp, err := pipeline.Analyze(in.data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := pipeline.PatchIDs(resp.Suggestions)
+	if p.patches != "" {
+		ids = strings.Split(p.patches, ",")
+	}
+	applied := pipeline.Apply(resp.Original, ids)
+	return resp.Normalized, applied.Simplified, nil
+}
+
+func runApply(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var pf patchFlags
+	pf.register(fs)
+	write := fs.Bool("w", false, "write the result back to the source files instead of printing it")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if err := setup(pf.catalogPath, pf.configPath); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	files, err := expandInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	if !*write && len(files) > 1 {
+		fmt.Fprintln(stderr, "apply prints a single policy; use -w to rewrite several files")
+		return exitError
+	}
+
+	code := exitOK
+	for _, path := range files {
+		if *write && path == "-" {
+			fmt.Fprintln(stderr, "cannot use -w with standard input")
+			return exitError
+		}
+
+		in, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = exitError
+			continue
+		}
+		_, simplified, err := pf.simplify(in)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = exitError
+			continue
+		}
+
+		out, err := encodePolicy(simplified, parser.DetectFormat(in.data))
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = exitError
+			continue
+		}
+
+		if !*write {
+			stdout.Write(out)
+			continue
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			fmt.Fprintln(stderr, err)
+			code = exitError
+		}
+	}
+	return code
+}
+
+// encodePolicy writes the policy back in the format it was read in.
+func encodePolicy(p *model.Policy, format parser.Format) ([]byte, error) {
+	if format == parser.FormatYAML {
+		return parser.MarshalYAML(p)
+	}
+	out, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
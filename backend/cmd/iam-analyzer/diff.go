@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Kuba0517/iam-analyzer/internal/diff"
+)
+
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var pf patchFlags
+	pf.register(fs)
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when any policy would change")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if err := setup(pf.catalogPath, pf.configPath); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	files, err := expandInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	code := exitOK
+	for _, path := range files {
+		in, err := readInput(path, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = exitError
+			continue
+		}
+		normalized, simplified, err := pf.simplify(in)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = exitError
+			continue
+		}
+
+		before, _ := json.Marshal(normalized)
+		after, _ := json.Marshal(simplified)
+		if string(before) == string(after) {
+			continue
+		}
+
+		out, err := diff.Unified(path+" (normalized)", normalized, path+" (simplified)", simplified)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = exitError
+			continue
+		}
+		fmt.Fprint(stdout, out)
+		if *exitCode && code == exitOK {
+			code = exitThreshold
+		}
+	}
+	return code
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+)
+
+const (
+	exitOK        = 0
+	exitThreshold = 1
+	exitError     = 2
+)
+
+const usage = `usage: iam-analyzer <command> [flags] <file|dir|glob>...
+
+commands:
+  analyze   report findings and score, failing on the configured thresholds
+  apply     apply suggested simplifications and print or write the result
+  diff      show the diff the suggested simplifications would make
+
+Run "iam-analyzer <command> -h" for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "analyze":
+		return runAnalyze(args, stdin, stdout, stderr)
+	case "apply":
+		return runApply(args, stdin, stdout, stderr)
+	case "diff":
+		return runDiff(args, stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", cmd, usage)
+		return exitError
+	}
+}
+
+// setup mirrors the server's environment handling so the CLI and the API
+// report the same findings for the same configuration.
+func setup(catalogPath, configPath string) error {
+	if catalogPath == "" {
+		catalogPath = os.Getenv("IAM_ANALYZER_CATALOG")
+	}
+	if catalogPath != "" {
+		cat, err := catalog.Load(catalogPath)
+		if err != nil {
+			return fmt.Errorf("load catalog: %w", err)
+		}
+		catalog.SetDefault(cat)
+	}
+
+	if configPath == "" {
+		configPath = os.Getenv("IAM_ANALYZER_CONFIG")
+	}
+	if configPath != "" {
+		cfg, err := analyzer.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("load analyzer config: %w", err)
+		}
+		analyzer.SetConfig(cfg)
+	}
+	return nil
+}
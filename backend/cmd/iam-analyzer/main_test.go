@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cleanPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`
+
+const adminPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`
+
+const duplicatePolicy = `{"Version":"2012-10-17","Statement":[
+	{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"},
+	{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`
+
+func writePolicies(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAnalyze_ExitCodes(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"clean.json":       cleanPolicy,
+		"nested/dup.json":  duplicatePolicy,
+		"admin/admin.json": adminPolicy,
+		"notes.txt":        "not a policy",
+	})
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"analyze", filepath.Join(dir, "clean.json")}, exitOK},
+		{[]string{"analyze", filepath.Join(dir, "nested")}, exitOK},
+		{[]string{"analyze", "-fail-on", "medium", filepath.Join(dir, "nested")}, exitThreshold},
+		{[]string{"analyze", filepath.Join(dir, "admin", "*.json")}, exitThreshold},
+		{[]string{"analyze", "-fail-on", "none", "-max-rank", "A", filepath.Join(dir, "admin")}, exitThreshold},
+		{[]string{"analyze", "-fail-on", "none", "-max-score", "50", dir}, exitOK},
+		{[]string{"analyze", filepath.Join(dir, "missing.json")}, exitError},
+		{[]string{"analyze", "-fail-on", "critical", dir}, exitError},
+		{[]string{"lint", dir}, exitError},
+	}
+
+	for _, tt := range tests {
+		if code, _, stderr := runCLI(tt.args...); code != tt.want {
+			t.Errorf("%v: exit %d, want %d (stderr: %s)", tt.args, code, tt.want, stderr)
+		}
+	}
+}
+
+func TestAnalyze_JSONOutput(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"a.json": adminPolicy, "b.json": cleanPolicy})
+
+	code, stdout, _ := runCLI("analyze", "-format", "json", "-fail-on", "none", dir)
+	if code != exitOK {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+
+	var reports []fileReport
+	if err := json.Unmarshal([]byte(stdout), &reports); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(reports) != 2 || !strings.HasSuffix(reports[0].File, "a.json") {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	if len(reports[0].Findings) == 0 || reports[0].Findings[0].RuleID != "wildcard" {
+		t.Errorf("expected a wildcard finding, got %+v", reports[0].Findings)
+	}
+}
+
+func TestApplyAndDiff(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"dup.json": duplicatePolicy, "clean.json": cleanPolicy})
+	dup := filepath.Join(dir, "dup.json")
+
+	code, stdout, _ := runCLI("diff", "-exit-code", dir)
+	if code != exitThreshold || !strings.Contains(stdout, "dup.json (simplified)") || strings.Contains(stdout, "clean.json") {
+		t.Errorf("unexpected diff result %d:\n%s", code, stdout)
+	}
+
+	if code, _, stderr := runCLI("apply", dir); code != exitError {
+		t.Errorf("expected apply on several files without -w to fail, got %d (%s)", code, stderr)
+	}
+
+	if code, _, stderr := runCLI("apply", "-w", dup); code != exitOK {
+		t.Fatalf("apply -w failed with %d: %s", code, stderr)
+	}
+	if code, stdout, _ := runCLI("diff", "-exit-code", dup); code != exitOK || stdout != "" {
+		t.Errorf("expected no diff after apply, got %d:\n%s", code, stdout)
+	}
+}
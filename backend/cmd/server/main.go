@@ -9,6 +9,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
 	"github.com/Kuba0517/iam-analyzer/internal/handler"
 )
 
@@ -18,6 +20,25 @@ func main() {
 		port = "8080"
 	}
 
+	// Air-gapped deployments can point at a newer catalog than the embedded one.
+	if path := os.Getenv("IAM_ANALYZER_CATALOG"); path != "" {
+		cat, err := catalog.Load(path)
+		if err != nil {
+			log.Fatalf("load catalog: %v", err)
+		}
+		catalog.SetDefault(cat)
+		log.Printf("using action catalog %s from %s", cat.Version, path)
+	}
+
+	if path := os.Getenv("IAM_ANALYZER_CONFIG"); path != "" {
+		cfg, err := analyzer.LoadConfig(path)
+		if err != nil {
+			log.Fatalf("load analyzer config: %v", err)
+		}
+		analyzer.SetConfig(cfg)
+		log.Printf("using analyzer config from %s", path)
+	}
+
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
@@ -27,7 +48,13 @@ func main() {
 
 	r.Get("/healthz", handler.Healthz)
 	r.Post("/analyze", handler.Analyze)
+	r.Post("/analyze/cloudformation", handler.AnalyzeTemplate)
+	r.Post("/analyze/terraform", handler.AnalyzeTerraform)
+	r.Post("/analyze/account", handler.AnalyzeAccount)
 	r.Post("/apply", handler.Apply)
+	r.Post("/simulate", handler.Simulate)
+	r.Post("/evaluate", handler.Evaluate)
+	r.Post("/permissions", handler.Permissions)
 
 	log.Printf("listening on :%s", port)
 	log.Fatal(http.ListenAndServe(":"+port, r))
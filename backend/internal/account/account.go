@@ -0,0 +1,220 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+var ErrInvalidDump = errors.New("invalid account authorization details")
+
+// Dump is the output of aws iam get-account-authorization-details.
+type Dump struct {
+	Users    []User          `json:"UserDetailList"`
+	Groups   []Group         `json:"GroupDetailList"`
+	Roles    []Role          `json:"RoleDetailList"`
+	Policies []ManagedPolicy `json:"Policies"`
+}
+
+type User struct {
+	UserName                string           `json:"UserName"`
+	Arn                     string           `json:"Arn"`
+	GroupList               []string         `json:"GroupList"`
+	UserPolicyList          []InlinePolicy   `json:"UserPolicyList"`
+	AttachedManagedPolicies []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+type Group struct {
+	GroupName               string           `json:"GroupName"`
+	Arn                     string           `json:"Arn"`
+	GroupPolicyList         []InlinePolicy   `json:"GroupPolicyList"`
+	AttachedManagedPolicies []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+type Role struct {
+	RoleName                 string           `json:"RoleName"`
+	Arn                      string           `json:"Arn"`
+	AssumeRolePolicyDocument Document         `json:"AssumeRolePolicyDocument"`
+	RolePolicyList           []InlinePolicy   `json:"RolePolicyList"`
+	AttachedManagedPolicies  []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+type InlinePolicy struct {
+	PolicyName     string   `json:"PolicyName"`
+	PolicyDocument Document `json:"PolicyDocument"`
+}
+
+type AttachedPolicy struct {
+	PolicyName string `json:"PolicyName"`
+	PolicyArn  string `json:"PolicyArn"`
+}
+
+type ManagedPolicy struct {
+	PolicyName        string          `json:"PolicyName"`
+	Arn               string          `json:"Arn"`
+	DefaultVersionId  string          `json:"DefaultVersionId"`
+	AttachmentCount   int             `json:"AttachmentCount"`
+	PolicyVersionList []PolicyVersion `json:"PolicyVersionList"`
+}
+
+type PolicyVersion struct {
+	Document         Document `json:"Document"`
+	VersionId        string   `json:"VersionId"`
+	IsDefaultVersion bool     `json:"IsDefaultVersion"`
+}
+
+// Document is a policy document as raw JSON. The CLI prints documents as
+// objects, the API returns them URL-encoded; both are accepted.
+type Document []byte
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = nil
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if decoded, err := url.PathUnescape(s); err == nil {
+			s = decoded
+		}
+		*d = Document(s)
+		return nil
+	}
+	*d = append(Document(nil), data...)
+	return nil
+}
+
+// DefaultDocument returns the document of the default version.
+func (p ManagedPolicy) DefaultDocument() Document {
+	for _, v := range p.PolicyVersionList {
+		if v.IsDefaultVersion || (p.DefaultVersionId != "" && v.VersionId == p.DefaultVersionId) {
+			return v.Document
+		}
+	}
+	return nil
+}
+
+// IsDump reports whether raw looks like authorization details rather than a
+// single policy.
+func IsDump(raw []byte) bool {
+	var keys map[string]json.RawMessage
+	if json.Unmarshal(raw, &keys) != nil {
+		return false
+	}
+	for _, k := range []string{"UserDetailList", "GroupDetailList", "RoleDetailList"} {
+		if _, ok := keys[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func Parse(raw []byte) (*Dump, error) {
+	var d Dump
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	if !IsDump(raw) {
+		return nil, fmt.Errorf("%w: expected UserDetailList, GroupDetailList or RoleDetailList", ErrInvalidDump)
+	}
+	return &d, nil
+}
+
+// Source is one policy document that applies to an entity.
+type Source struct {
+	Name string
+	Arn  string
+
+	// Kind is "inline" or "managed".
+	Kind string
+
+	// Via is the group the policy comes from, for users.
+	Via string
+
+	Document Document
+}
+
+// Entity is a user, group, role or managed policy with every policy document
+// that applies to it. Missing lists attached managed policies that are not in
+// the dump.
+type Entity struct {
+	Type    string
+	Name    string
+	Arn     string
+	Sources []Source
+	Missing []string
+
+	// Trust is the assume role policy of a role.
+	Trust Document
+}
+
+// Entities resolves attachments and group membership, returning users, groups,
+// roles and managed policies in that order.
+func (d *Dump) Entities() []Entity {
+	managed := make(map[string]ManagedPolicy, len(d.Policies))
+	for _, p := range d.Policies {
+		managed[p.Arn] = p
+	}
+	groups := make(map[string]Group, len(d.Groups))
+	for _, g := range d.Groups {
+		groups[g.GroupName] = g
+	}
+
+	attach := func(e *Entity, attached []AttachedPolicy, via string) {
+		for _, a := range attached {
+			p, ok := managed[a.PolicyArn]
+			doc := p.DefaultDocument()
+			if !ok || doc == nil {
+				e.Missing = append(e.Missing, a.PolicyArn)
+				continue
+			}
+			e.Sources = append(e.Sources, Source{Name: a.PolicyName, Arn: a.PolicyArn, Kind: "managed", Via: via, Document: doc})
+		}
+	}
+	inline := func(e *Entity, policies []InlinePolicy, via string) {
+		for _, p := range policies {
+			e.Sources = append(e.Sources, Source{Name: p.PolicyName, Kind: "inline", Via: via, Document: p.PolicyDocument})
+		}
+	}
+
+	var entities []Entity
+	for _, u := range d.Users {
+		e := Entity{Type: "user", Name: u.UserName, Arn: u.Arn}
+		inline(&e, u.UserPolicyList, "")
+		attach(&e, u.AttachedManagedPolicies, "")
+		for _, name := range u.GroupList {
+			g, ok := groups[name]
+			if !ok {
+				continue
+			}
+			inline(&e, g.GroupPolicyList, name)
+			attach(&e, g.AttachedManagedPolicies, name)
+		}
+		entities = append(entities, e)
+	}
+	for _, g := range d.Groups {
+		e := Entity{Type: "group", Name: g.GroupName, Arn: g.Arn}
+		inline(&e, g.GroupPolicyList, "")
+		attach(&e, g.AttachedManagedPolicies, "")
+		entities = append(entities, e)
+	}
+	for _, r := range d.Roles {
+		e := Entity{Type: "role", Name: r.RoleName, Arn: r.Arn, Trust: r.AssumeRolePolicyDocument}
+		inline(&e, r.RolePolicyList, "")
+		attach(&e, r.AttachedManagedPolicies, "")
+		entities = append(entities, e)
+	}
+	for _, p := range d.Policies {
+		e := Entity{Type: "policy", Name: p.PolicyName, Arn: p.Arn}
+		if doc := p.DefaultDocument(); doc != nil {
+			e.Sources = []Source{{Name: p.PolicyName, Arn: p.Arn, Kind: "managed", Document: doc}}
+		}
+		entities = append(entities, e)
+	}
+	return entities
+}
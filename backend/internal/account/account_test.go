@@ -0,0 +1,102 @@
+package account_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/account"
+)
+
+const dump = `{
+  "UserDetailList": [{
+    "UserName": "alice",
+    "Arn": "arn:aws:iam::123456789012:user/alice",
+    "GroupList": ["admins", "gone"],
+    "UserPolicyList": [{
+      "PolicyName": "own",
+      "PolicyDocument": "%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%5D%7D"
+    }],
+    "AttachedManagedPolicies": [{"PolicyName": "ReadOnlyAccess", "PolicyArn": "arn:aws:iam::aws:policy/ReadOnlyAccess"}]
+  }],
+  "GroupDetailList": [{
+    "GroupName": "admins",
+    "Arn": "arn:aws:iam::123456789012:group/admins",
+    "GroupPolicyList": [],
+    "AttachedManagedPolicies": [{"PolicyName": "Admin", "PolicyArn": "arn:aws:iam::123456789012:policy/Admin"}]
+  }],
+  "RoleDetailList": [{
+    "RoleName": "app",
+    "Arn": "arn:aws:iam::123456789012:role/app",
+    "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": {"Service": "ec2.amazonaws.com"}, "Action": "sts:AssumeRole"}]},
+    "RolePolicyList": [],
+    "AttachedManagedPolicies": []
+  }],
+  "Policies": [{
+    "PolicyName": "Admin",
+    "Arn": "arn:aws:iam::123456789012:policy/Admin",
+    "DefaultVersionId": "v2",
+    "PolicyVersionList": [
+      {"VersionId": "v1", "IsDefaultVersion": false, "Document": {"Version": "2012-10-17", "Statement": []}},
+      {"VersionId": "v2", "IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}}
+    ]
+  }]
+}`
+
+func TestEntities(t *testing.T) {
+	if !account.IsDump([]byte(dump)) {
+		t.Fatal("expected the dump to be recognized")
+	}
+	d, err := account.Parse([]byte(dump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entities := d.Entities()
+	var got []string
+	for _, e := range entities {
+		got = append(got, e.Type+"/"+e.Name)
+	}
+	want := "user/alice group/admins role/app policy/Admin"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("expected %s, got %v", want, got)
+	}
+
+	alice := entities[0]
+	if len(alice.Sources) != 2 || alice.Sources[0].Kind != "inline" || alice.Sources[1].Via != "admins" {
+		t.Fatalf("unexpected sources %+v", alice.Sources)
+	}
+	if string(alice.Sources[0].Document) != `{"Version":"2012-10-17","Statement":[]}` {
+		t.Errorf("expected the URL-encoded document to be decoded, got %s", alice.Sources[0].Document)
+	}
+	if !strings.Contains(string(alice.Sources[1].Document), `"Action": "*"`) {
+		t.Errorf("expected the default version, got %s", alice.Sources[1].Document)
+	}
+	if len(alice.Missing) != 1 || alice.Missing[0] != "arn:aws:iam::aws:policy/ReadOnlyAccess" {
+		t.Errorf("unexpected missing policies %v", alice.Missing)
+	}
+
+	if entities[2].Trust == nil {
+		t.Error("expected the role to carry its trust policy")
+	}
+}
+
+func TestParse_NotDump(t *testing.T) {
+	raw := []byte(`{"Version": "2012-10-17", "Statement": []}`)
+	if account.IsDump(raw) {
+		t.Error("a policy is not a dump")
+	}
+	if _, err := account.Parse(raw); !errors.Is(err, account.ErrInvalidDump) {
+		t.Errorf("expected ErrInvalidDump, got %v", err)
+	}
+}
+
+func TestDocument_KeepsPlus(t *testing.T) {
+	var d account.Document
+	if err := d.UnmarshalJSON([]byte(`"%7B%22Sid%22%3A%22a+b%22%7D"`)); err != nil {
+		t.Fatal(err)
+	}
+	if string(d) != `{"Sid":"a+b"}` {
+		t.Errorf("expected + to stay literal, got %s", d)
+	}
+}
@@ -3,28 +3,56 @@ package analyzer
 import (
 	"sort"
 
-	"github.com/Kuba0517/iam-analyzer/internal/graph"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
 
 func Analyze(p *model.Policy) []model.Finding {
-	g := graph.Build(p)
+	return AnalyzeWithConfig(p, currentConfig.Load())
+}
+
+// AnalyzeWithMapping is Analyze for a normalized policy; evidence and
+// OriginalIndices refer to statements through mapping.
+func AnalyzeWithMapping(p *model.Policy, mapping model.StatementMapping) []model.Finding {
+	return run(NewContext(p, mapping), currentConfig.Load())
+}
+
+// AnalyzeContext runs the enabled rules over a context the caller prepared,
+// for instance with a graph it already built or the account that owns the
+// policy.
+func AnalyzeContext(ctx *Context) []model.Finding {
+	return run(ctx, currentConfig.Load())
+}
+
+func AnalyzeWithConfig(p *model.Policy, cfg *Config) []model.Finding {
+	return run(NewContext(p, nil), cfg)
+}
 
+func run(ctx *Context, cfg *Config) []model.Finding {
 	var findings []model.Finding
-	findings = append(findings, detectRedundantFromGraph(g)...)
-	findings = append(findings, detectMergeCandidatesFromGraph(g)...)
-	findings = append(findings, DetectWildcardOveruse(p)...)
-	findings = append(findings, DetectNegativeElements(p)...)
-	findings = append(findings, detectDenyAllowOverlapFromGraph(g, p)...)
+	for _, r := range Rules() {
+		if !cfg.Enabled(r.ID()) {
+			continue
+		}
+		override := cfg.severity(r.ID())
+		for _, f := range r.Check(ctx) {
+			f.RuleID = r.ID()
+			f.OriginalIndices = ctx.Mapping.Originals(f.StmtIndices)
+			if override != "" {
+				f.Severity = override
+			}
+			findings = append(findings, f)
+		}
+	}
 
 	sort.SliceStable(findings, func(i, j int) bool {
-		return severityRank(findings[i].Severity) > severityRank(findings[j].Severity)
+		return SeverityRank(findings[i].Severity) > SeverityRank(findings[j].Severity)
 	})
 
 	return findings
 }
 
-func severityRank(s model.Severity) int {
+// SeverityRank orders severities from 0 for unknown ones to 3 for high.
+func SeverityRank(s model.Severity) int {
 	switch s {
 	case model.SeverityHigh:
 		return 3
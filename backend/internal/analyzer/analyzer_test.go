@@ -1,9 +1,11 @@
 package analyzer_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
 
@@ -33,7 +35,7 @@ func TestAnalyze_SortsBySeverity(t *testing.T) {
 
 	findings := analyzer.Analyze(p)
 	for i := 1; i < len(findings); i++ {
-		if severityRank(findings[i].Severity) > severityRank(findings[i-1].Severity) {
+		if analyzer.SeverityRank(findings[i].Severity) > analyzer.SeverityRank(findings[i-1].Severity) {
 			t.Errorf("findings not sorted by severity: %s before %s", findings[i-1].Severity, findings[i].Severity)
 		}
 	}
@@ -72,6 +74,46 @@ func TestDetectRedundant_NoDuplicates(t *testing.T) {
 	}
 }
 
+func TestDetectSubsumed(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/logs/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+		},
+	}
+
+	if findings := analyzer.DetectRedundant(p); len(findings) != 0 {
+		t.Fatalf("expected no redundant findings, got %+v", findings)
+	}
+	findings := analyzer.DetectSubsumed(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Title != "Subsumed statement" || !strings.Contains(findings[0].Evidence, "Statement 1 covers statement 0") {
+		t.Errorf("unexpected finding %+v", findings[0])
+	}
+}
+
+func TestDetectDeadStatements(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:CreateUser"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"iam:*"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	findings := analyzer.DetectDeadStatements(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if got := findings[0].StmtIndices; len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Errorf("expected statements [0 1], got %v", got)
+	}
+}
+
 func TestDetectMergeCandidates_SameResources(t *testing.T) {
 	p := &model.Policy{
 		Version: "2012-10-17",
@@ -281,16 +323,487 @@ func TestDetectDenyAllowOverlap_NoDuplicateFindings(t *testing.T) {
 	}
 }
 
-// helper to mirror the unexported severityRank in analyzer.go
-func severityRank(s model.Severity) int {
-	switch s {
-	case model.SeverityHigh:
-		return 3
-	case model.SeverityMedium:
-		return 2
-	case model.SeverityLow:
-		return 1
-	default:
-		return 0
+func TestDetectDenyAllowOverlap_Conditional(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"*"},
+				Condition: model.Condition{"Bool": {"aws:SecureTransport": model.NewConditionValue("false")}}},
+		},
+	}
+
+	findings := analyzer.DetectDenyAllowOverlap(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Severity != model.SeverityMedium {
+		t.Errorf("expected medium severity for a conditional Deny, got %s", findings[0].Severity)
+	}
+	if !strings.Contains(findings[0].Evidence, `on arn:aws:s3:::b/*`) {
+		t.Errorf("expected the overlapping resource in the evidence, got %q", findings[0].Evidence)
+	}
+}
+
+func TestDetectInvalidConditions(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Action:    model.StringOrSlice{"s3:ListBucket"},
+				Resource:  model.StringOrSlice{"*"},
+				Condition: model.Condition{"NumericLessThan": {"s3:max-keys": model.NewConditionValue("ten")}},
+			},
+			{
+				Effect:    "Allow",
+				Action:    model.StringOrSlice{"s3:GetObject"},
+				Resource:  model.StringOrSlice{"*"},
+				Condition: model.Condition{"StringEqualz": {"aws:PrincipalTag/team": model.NewConditionValue("data")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectInvalidConditions(p)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+}
+
+func TestDetectInvalidConditions_Valid(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Action:    model.StringOrSlice{"s3:GetObject"},
+				Resource:  model.StringOrSlice{"*"},
+				Condition: model.Condition{"ForAnyValue:StringLikeIfExists": {"aws:TagKeys": model.NewConditionValue("team-*")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectInvalidConditions(p)
+	if len(findings) != 0 {
+		t.Fatalf("expected 0 findings, got %d", len(findings))
+	}
+}
+
+func TestDetectUnknownActions_Typo(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObjet"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	findings := analyzer.DetectUnknownActions(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if !strings.Contains(findings[0].Evidence, `did you mean "s3:GetObject"`) {
+		t.Errorf("expected suggestion in evidence, got %q", findings[0].Evidence)
+	}
+	if findings[0].Severity != model.SeverityMedium {
+		t.Errorf("expected medium severity, got %s", findings[0].Severity)
+	}
+}
+
+func TestDetectUnknownActions_DenyTypoIsHigh(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Deny", Action: model.StringOrSlice{"iam:DeleteRolle"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	findings := analyzer.DetectUnknownActions(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Severity != model.SeverityHigh {
+		t.Errorf("expected high severity, got %s", findings[0].Severity)
+	}
+}
+
+func TestDetectUnknownActions_PatternMatchesNothing(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:Fetch*", "s3:Unlisted"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	if findings := analyzer.DetectUnknownActions(p); len(findings) != 0 {
+		t.Fatalf("expected no findings against the partial catalog, got %+v", findings)
+	}
+
+	cat, err := catalog.Parse([]byte(`{"version": "test", "services": [{"prefix": "s3", "complete": true, "actions": [
+		{"name": "GetObject", "accessLevel": "Read"}
+	]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := catalog.Default()
+	catalog.SetDefault(cat)
+	t.Cleanup(func() { catalog.SetDefault(prev) })
+
+	findings := analyzer.DetectUnknownActions(p)
+	if len(findings) != 2 || findings[0].Title != "Action pattern matches nothing" || findings[1].Title != "Unknown action" {
+		t.Fatalf("expected both entries reported for a complete service, got %+v", findings)
+	}
+}
+
+func TestDetectUnknownActions_KnownAndUncatalogued(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject", "s3:List*", "*", "braket:CreateQuantumTask"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	findings := analyzer.DetectUnknownActions(p)
+	if len(findings) != 0 {
+		t.Fatalf("expected 0 findings, got %+v", findings)
+	}
+}
+
+func TestDetectPrivilegeEscalation_AcrossStatements(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:PassRole"}, Resource: model.StringOrSlice{"arn:aws:iam::111122223333:role/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"lambda:CreateFunction"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"lambda:Invoke*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	findings := analyzer.DetectPrivilegeEscalation(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Severity != model.SeverityHigh {
+		t.Errorf("expected high severity, got %s", f.Severity)
+	}
+	if f.Title != "Privilege escalation: PassRole to new Lambda function" {
+		t.Errorf("unexpected title: %s", f.Title)
+	}
+	if len(f.StmtIndices) != 3 || f.StmtIndices[0] != 0 || f.StmtIndices[2] != 2 {
+		t.Errorf("expected statements [0 1 2], got %v", f.StmtIndices)
+	}
+}
+
+func TestDetectPrivilegeEscalation_ServiceWildcard(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:Attach*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	titles := make(map[string]bool)
+	for _, f := range analyzer.DetectPrivilegeEscalation(p) {
+		titles[f.Title] = true
+	}
+	for _, want := range []string{
+		"Privilege escalation: Attach managed policy to user",
+		"Privilege escalation: Attach managed policy to group",
+		"Privilege escalation: Attach managed policy to role",
+	} {
+		if !titles[want] {
+			t.Errorf("expected finding %q", want)
+		}
+	}
+	if titles["Privilege escalation: Put inline user policy"] {
+		t.Error("iam:Attach* must not match iam:PutUserPolicy")
+	}
+}
+
+func TestDetectPrivilegeEscalation_DeniedOrIncomplete(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:PassRole", "ec2:RunInstances", "iam:CreatePolicyVersion"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"iam:PassRole", "iam:CreatePolicyVersion"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"glue:CreateDevEndpoint"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	if findings := analyzer.DetectPrivilegeEscalation(p); len(findings) != 0 {
+		t.Fatalf("expected 0 findings, got %+v", findings)
+	}
+}
+
+func TestClassifyPrincipal(t *testing.T) {
+	const owner = "111122223333"
+	tests := []struct {
+		name string
+		stmt model.Statement
+		want analyzer.PrincipalClass
+	}{
+		{"wildcard", model.Statement{Effect: "Allow", Principal: &model.Principal{Wildcard: true}}, analyzer.PrincipalPublic},
+		{"aws wildcard", model.Statement{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"AWS": {"*"}}}}, analyzer.PrincipalPublic},
+		{"same account", model.Statement{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:role/app"}}}}, analyzer.PrincipalSameAccount},
+		{"other account", model.Statement{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"AWS": {"444455556666"}}}}, analyzer.PrincipalCrossAccount},
+		{"service", model.Statement{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"Service": {"sns.amazonaws.com"}}}}, analyzer.PrincipalService},
+		{"not principal", model.Statement{Effect: "Allow", NotPrincipal: &model.Principal{Members: map[string][]string{"AWS": {"444455556666"}}}}, analyzer.PrincipalPublic},
+		{"deny", model.Statement{Effect: "Deny", Principal: &model.Principal{Wildcard: true}}, analyzer.PrincipalNone},
+		{"identity policy", model.Statement{Effect: "Allow"}, analyzer.PrincipalNone},
+	}
+
+	for _, tt := range tests {
+		if got := analyzer.ClassifyPrincipal(tt.stmt, owner); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectPublicAccess_PublicBucket(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Principal: &model.Principal{Wildcard: true}, Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::site/*"}},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Wildcard: true},
+				Action:    model.StringOrSlice{"s3:PutObject"},
+				Resource:  model.StringOrSlice{"arn:aws:s3:::site/*"},
+				Condition: model.Condition{"StringEquals": {"aws:PrincipalOrgID": model.NewConditionValue("o-abc123")}},
+			},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Wildcard: true},
+				Action:    model.StringOrSlice{"s3:DeleteObject"},
+				Resource:  model.StringOrSlice{"arn:aws:s3:::site/*"},
+				Condition: model.Condition{"StringEqualsIfExists": {"aws:SourceVpce": model.NewConditionValue("vpce-1")}},
+			},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Wildcard: true},
+				Action:    model.StringOrSlice{"s3:PutObjectAcl"},
+				Resource:  model.StringOrSlice{"arn:aws:s3:::site/*"},
+				Condition: model.Condition{"StringNotEquals": {"aws:PrincipalOrgID": model.NewConditionValue("o-evil")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectPublicAccess(p)
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got %d: %+v", len(findings), findings)
+	}
+	want := []model.Severity{model.SeverityHigh, model.SeverityMedium, model.SeverityHigh, model.SeverityHigh}
+	for i, f := range findings {
+		if f.Title != "Public access" || f.Severity != want[i] {
+			t.Errorf("finding %d: got %s/%s, want Public access/%s", i, f.Title, f.Severity, want[i])
+		}
+	}
+}
+
+func TestDetectPublicAccess_QueuePolicy(t *testing.T) {
+	queue := model.StringOrSlice{"arn:aws:sqs:us-east-1:111122223333:orders"}
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:role/worker"}}}, Action: model.StringOrSlice{"sqs:ReceiveMessage"}, Resource: queue},
+			{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::444455556666:root"}}}, Action: model.StringOrSlice{"sqs:SendMessage"}, Resource: queue},
+			{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"Service": {"sns.amazonaws.com"}}}, Action: model.StringOrSlice{"sqs:SendMessage"}, Resource: queue},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"Service": {"events.amazonaws.com"}}},
+				Action:    model.StringOrSlice{"sqs:SendMessage"},
+				Resource:  queue,
+				Condition: model.Condition{"ArnEquals": {"aws:SourceArn": model.NewConditionValue("arn:aws:events:us-east-1:111122223333:rule/orders")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectPublicAccess(p)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Title != "Cross-account access" || findings[0].Severity != model.SeverityMedium {
+		t.Errorf("unexpected first finding: %+v", findings[0])
+	}
+	if !strings.Contains(findings[0].Evidence, "444455556666") {
+		t.Errorf("expected evidence to name the foreign account, got %q", findings[0].Evidence)
+	}
+	if findings[1].Title != "Service principal without source restriction" || findings[1].StmtIndices[0] != 2 {
+		t.Errorf("unexpected second finding: %+v", findings[1])
+	}
+}
+
+func TestDetectPublicAccess_Placeholders(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::${AWS::AccountId}:root", "${Worker.Arn}", "${aws_iam_role.worker.arn}"}}},
+				Action:    model.StringOrSlice{"sqs:SendMessage"},
+				Resource:  model.StringOrSlice{"arn:aws:sqs:us-east-1:${AWS::AccountId}:orders"},
+			},
+		},
+	}
+
+	if findings := analyzer.DetectPublicAccess(p); len(findings) != 0 {
+		t.Fatalf("expected unresolved principals not to be reported, got %+v", findings)
+	}
+	if findings := analyzer.DetectTrustPolicyIssues(p); len(findings) != 0 {
+		t.Fatalf("expected unresolved accounts not to be reported, got %+v", findings)
+	}
+}
+
+func TestIsTrustPolicy(t *testing.T) {
+	trust := &model.Policy{Statement: []model.Statement{
+		{Effect: "Allow", Principal: &model.Principal{Members: map[string][]string{"Service": {"ec2.amazonaws.com"}}}, Action: model.StringOrSlice{"sts:AssumeRole"}},
+	}}
+	if !analyzer.IsTrustPolicy(trust) {
+		t.Error("expected a trust policy")
+	}
+
+	bucket := &model.Policy{Statement: []model.Statement{
+		{Effect: "Allow", Principal: &model.Principal{Wildcard: true}, Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+	}}
+	if analyzer.IsTrustPolicy(bucket) {
+		t.Error("a bucket policy is not a trust policy")
+	}
+}
+
+func TestDetectTrustPolicyIssues_ExternalAccount(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::444455556666:root", "777788889999"}}},
+				Action:    model.StringOrSlice{"sts:AssumeRole"},
+			},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::222233334444:role/vendor"}}},
+				Action:    model.StringOrSlice{"sts:AssumeRole"},
+				Condition: model.Condition{"StringEquals": {"sts:ExternalId": model.NewConditionValue("f00d")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectTrustPolicyIssues(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Evidence != "Statement 0 trusts account 444455556666, 777788889999" {
+		t.Errorf("unexpected evidence: %q", findings[0].Evidence)
+	}
+}
+
+func TestDetectTrustPolicyIssues_OwnAccount(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{{
+			Effect:    "Allow",
+			Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:root", "arn:aws:iam::444455556666:root"}}},
+			Action:    model.StringOrSlice{"sts:AssumeRole"},
+		}},
+	}
+
+	ctx := analyzer.NewContext(p, nil)
+	ctx.Account = "111122223333"
+	var evidence []string
+	for _, f := range analyzer.AnalyzeContext(ctx) {
+		if f.RuleID == "trust-policy" {
+			evidence = append(evidence, f.Evidence)
+		}
+	}
+	if len(evidence) != 1 || evidence[0] != "Statement 0 trusts account 444455556666" {
+		t.Errorf("expected only the other account to be reported, got %q", evidence)
+	}
+
+	p.Statement[0].Principal.Members["AWS"] = []string{"111122223333"}
+	for _, f := range analyzer.AnalyzeContext(ctx) {
+		if f.RuleID == "trust-policy" {
+			t.Errorf("expected the role's own account to be trusted silently, got %+v", f)
+		}
+	}
+}
+
+func TestDetectTrustPolicyIssues_GitHubOIDC(t *testing.T) {
+	provider := "arn:aws:iam::111122223333:oidc-provider/token.actions.githubusercontent.com"
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"Federated": {provider}}},
+				Action:    model.StringOrSlice{"sts:AssumeRoleWithWebIdentity"},
+				Condition: model.Condition{"StringEquals": {"token.actions.githubusercontent.com:aud": model.NewConditionValue("sts.amazonaws.com")}},
+			},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"Federated": {provider}}},
+				Action:    model.StringOrSlice{"sts:AssumeRoleWithWebIdentity"},
+				Condition: model.Condition{
+					"StringEquals": {"token.actions.githubusercontent.com:aud": model.NewConditionValue("sts.amazonaws.com")},
+					"StringLike":   {"token.actions.githubusercontent.com:sub": model.NewConditionValue("repo:acme/deploy:*")},
+				},
+			},
+		},
+	}
+
+	findings := analyzer.DetectTrustPolicyIssues(p)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Severity != model.SeverityHigh || f.StmtIndices[0] != 0 {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if !strings.HasSuffix(f.Evidence, "without token.actions.githubusercontent.com:sub") {
+		t.Errorf("unexpected evidence: %q", f.Evidence)
+	}
+}
+
+func TestDetectTrustPolicyIssues_NegatedSubject(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"Federated": {"arn:aws:iam::111122223333:oidc-provider/token.actions.githubusercontent.com"}}},
+				Action:    model.StringOrSlice{"sts:AssumeRoleWithWebIdentity"},
+				Condition: model.Condition{"StringNotLike": {"token.actions.githubusercontent.com:sub": model.NewConditionValue("repo:evil/*")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectTrustPolicyIssues(p)
+	if len(findings) != 1 || findings[0].Severity != model.SeverityHigh {
+		t.Fatalf("expected 1 high finding, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "token.actions.githubusercontent.com:sub") {
+		t.Errorf("expected the negated sub condition not to count, got %q", findings[0].Evidence)
+	}
+}
+
+func TestDetectTrustPolicyIssues_AnyPrincipal(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Principal: &model.Principal{Wildcard: true}, Action: model.StringOrSlice{"sts:AssumeRole"}},
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"AWS": {"*"}}},
+				Action:    model.StringOrSlice{"sts:AssumeRole"},
+				Condition: model.Condition{"StringEquals": {"aws:PrincipalOrgID": model.NewConditionValue("o-abc123")}},
+			},
+		},
+	}
+
+	findings := analyzer.DetectTrustPolicyIssues(p)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Severity != model.SeverityHigh || findings[1].Severity != model.SeverityMedium {
+		t.Errorf("unexpected severities: %s, %s", findings[0].Severity, findings[1].Severity)
 	}
 }
@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/Kuba0517/iam-analyzer/internal/condition"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func DetectInvalidConditions(p *model.Policy) []model.Finding {
+	return detectInvalidConditions(p, model.IdentityMapping(p))
+}
+
+func detectInvalidConditions(p *model.Policy, m model.StatementMapping) []model.Finding {
+	var findings []model.Finding
+
+	for i, s := range p.Statement {
+		for _, err := range condition.Validate(s.Condition) {
+			findings = append(findings, model.Finding{
+				Severity:    model.SeverityMedium,
+				Title:       "Invalid condition",
+				Explanation: "The condition uses an unknown operator or a value the operator cannot interpret. AWS rejects such policies, and a condition that never evaluates can silently disable the statement.",
+				Evidence:    fmt.Sprintf("Statement %s: %v", m.Ref(i), err),
+				StmtIndices: []int{i},
+			})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+var ErrInvalidConfig = errors.New("invalid analyzer config")
+
+type RuleConfig struct {
+	Enabled  *bool          `json:"enabled,omitempty"`
+	Severity model.Severity `json:"severity,omitempty"`
+}
+
+// Config turns rules on or off and overrides the severity of their findings.
+// Rules not mentioned keep their defaults.
+type Config struct {
+	Rules map[string]RuleConfig `json:"rules"`
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	for id, rc := range c.Rules {
+		if _, ok := LookupRule(id); !ok {
+			return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalidConfig, id)
+		}
+		switch rc.Severity {
+		case "", model.SeverityLow, model.SeverityMedium, model.SeverityHigh:
+		default:
+			return nil, fmt.Errorf("%w: rule %q has unknown severity %q", ErrInvalidConfig, id, rc.Severity)
+		}
+	}
+	return &c, nil
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+func (c *Config) Enabled(id string) bool {
+	if c == nil {
+		return true
+	}
+	rc, ok := c.Rules[id]
+	return !ok || rc.Enabled == nil || *rc.Enabled
+}
+
+func (c *Config) severity(id string) model.Severity {
+	if c == nil {
+		return ""
+	}
+	return c.Rules[id].Severity
+}
+
+var currentConfig atomic.Pointer[Config]
+
+// SetConfig installs the configuration used by Analyze. A nil config runs
+// every registered rule with its own severities.
+func SetConfig(c *Config) {
+	currentConfig.Store(c)
+}
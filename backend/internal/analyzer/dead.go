@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func DetectDeadStatements(p *model.Policy) []model.Finding {
+	return detectDeadStatementsFromGraph(graph.Build(p), model.IdentityMapping(p))
+}
+
+func detectDeadStatementsFromGraph(g *graph.Graph, m model.StatementMapping) []model.Finding {
+	var findings []model.Finding
+	for _, n := range g.Nodes() {
+		if len(n.ShadowedBy) == 0 {
+			continue
+		}
+		refs := make([]string, len(n.ShadowedBy))
+		for i, d := range n.ShadowedBy {
+			refs[i] = m.Ref(d)
+		}
+		findings = append(findings, model.Finding{
+			Severity:    model.SeverityMedium,
+			Title:       "Dead Allow statement",
+			Explanation: "Every action on every resource this statement allows is denied unconditionally, so it never grants anything.",
+			Evidence:    fmt.Sprintf("Allow statement %s is overridden by Deny statement %s", m.Ref(n.Index), strings.Join(refs, ", ")),
+			StmtIndices: append([]int{n.Index}, n.ShadowedBy...),
+		})
+	}
+	return findings
+}
@@ -2,16 +2,17 @@ package analyzer
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Kuba0517/iam-analyzer/internal/graph"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
 
 func DetectDenyAllowOverlap(p *model.Policy) []model.Finding {
-	return detectDenyAllowOverlapFromGraph(graph.Build(p), p)
+	return detectDenyAllowOverlapFromGraph(graph.Build(p), p, model.IdentityMapping(p))
 }
 
-func detectDenyAllowOverlapFromGraph(g *graph.Graph, p *model.Policy) []model.Finding {
+func detectDenyAllowOverlapFromGraph(g *graph.Graph, p *model.Policy, m model.StatementMapping) []model.Finding {
 	var findings []model.Finding
 
 	for _, e := range g.EdgesOfType(graph.DenyAllowOverlap) {
@@ -20,12 +21,24 @@ func detectDenyAllowOverlapFromGraph(g *graph.Graph, p *model.Policy) []model.Fi
 			allowIdx, denyIdx = denyIdx, allowIdx
 		}
 
+		severity := model.SeverityHigh
+		explanation := "Action %q is both allowed and denied. The Deny will take precedence, but this may indicate a misconfiguration."
+		if e.Meta.Conditional {
+			severity = model.SeverityMedium
+			explanation = "Action %q is both allowed and denied, but the Deny only applies when its conditions hold, so the Allow may still take effect."
+		}
+
+		on := ""
+		if len(e.Meta.OverlappingResources) > 0 {
+			on = fmt.Sprintf(" on %s", strings.Join(e.Meta.OverlappingResources, ", "))
+		}
+
 		for _, action := range e.Meta.OverlappingActions {
 			findings = append(findings, model.Finding{
-				Severity:    model.SeverityHigh,
+				Severity:    severity,
 				Title:       "Deny/Allow overlap",
-				Explanation: fmt.Sprintf("Action %q is both allowed and denied. The Deny will take precedence, but this may indicate a misconfiguration.", action),
-				Evidence:    fmt.Sprintf("Action %q in Allow statement %d and Deny statement %d", action, allowIdx, denyIdx),
+				Explanation: fmt.Sprintf(explanation, action),
+				Evidence:    fmt.Sprintf("Action %q%s in Allow statement %s and Deny statement %s", action, on, m.Ref(allowIdx), m.Ref(denyIdx)),
 				StmtIndices: []int{allowIdx, denyIdx},
 			})
 		}
@@ -8,10 +8,10 @@ import (
 )
 
 func DetectMergeCandidates(p *model.Policy) []model.Finding {
-	return detectMergeCandidatesFromGraph(graph.Build(p))
+	return detectMergeCandidatesFromGraph(graph.Build(p), model.IdentityMapping(p))
 }
 
-func detectMergeCandidatesFromGraph(g *graph.Graph) []model.Finding {
+func detectMergeCandidatesFromGraph(g *graph.Graph, m model.StatementMapping) []model.Finding {
 	var findings []model.Finding
 
 	for _, e := range g.EdgesOfType(graph.MergeableAction) {
@@ -19,7 +19,7 @@ func detectMergeCandidatesFromGraph(g *graph.Graph) []model.Finding {
 			Severity:    model.SeverityLow,
 			Title:       "Merge candidates (same resources)",
 			Explanation: "These statements share the same Effect, Resources, Conditions and Principal. Their Actions can be merged into one statement.",
-			Evidence:    fmt.Sprintf("Statements %d and %d can merge Actions", e.From, e.To),
+			Evidence:    fmt.Sprintf("Statements %s and %s can merge Actions", m.Ref(e.From), m.Ref(e.To)),
 			StmtIndices: []int{e.From, e.To},
 		})
 	}
@@ -29,7 +29,7 @@ func detectMergeCandidatesFromGraph(g *graph.Graph) []model.Finding {
 			Severity:    model.SeverityLow,
 			Title:       "Merge candidates (same actions)",
 			Explanation: "These statements share the same Effect, Actions, Conditions and Principal. Their Resources can be merged into one statement.",
-			Evidence:    fmt.Sprintf("Statements %d and %d can merge Resources", e.From, e.To),
+			Evidence:    fmt.Sprintf("Statements %s and %s can merge Resources", m.Ref(e.From), m.Ref(e.To)),
 			StmtIndices: []int{e.From, e.To},
 		})
 	}
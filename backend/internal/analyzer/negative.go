@@ -7,6 +7,10 @@ import (
 )
 
 func DetectNegativeElements(p *model.Policy) []model.Finding {
+	return detectNegativeElements(p, model.IdentityMapping(p))
+}
+
+func detectNegativeElements(p *model.Policy, m model.StatementMapping) []model.Finding {
 	var findings []model.Finding
 
 	for i, s := range p.Statement {
@@ -15,7 +19,7 @@ func DetectNegativeElements(p *model.Policy) []model.Finding {
 				Severity:    model.SeverityMedium,
 				Title:       "Usage of NotAction",
 				Explanation: "NotAction inverts the action match. This is error-prone and can unintentionally grant broad permissions.",
-				Evidence:    fmt.Sprintf("Statement %d uses NotAction", i),
+				Evidence:    fmt.Sprintf("Statement %s uses NotAction", m.Ref(i)),
 				StmtIndices: []int{i},
 			})
 		}
@@ -24,7 +28,7 @@ func DetectNegativeElements(p *model.Policy) []model.Finding {
 				Severity:    model.SeverityMedium,
 				Title:       "Usage of NotResource",
 				Explanation: "NotResource inverts the resource match. This is error-prone and can unintentionally expose resources.",
-				Evidence:    fmt.Sprintf("Statement %d uses NotResource", i),
+				Evidence:    fmt.Sprintf("Statement %s uses NotResource", m.Ref(i)),
 				StmtIndices: []int{i},
 			})
 		}
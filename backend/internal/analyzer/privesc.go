@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+type escalationPath struct {
+	name        string
+	actions     []string
+	explanation string
+}
+
+// Known IAM privilege-escalation combinations. A path is reported when every
+// one of its actions is granted somewhere in the policy, regardless of which
+// statement grants it.
+var escalationPaths = []escalationPath{
+	{"Create new policy version", []string{"iam:CreatePolicyVersion"},
+		"A new default version of a managed policy can grant any permission to whoever the policy is attached to."},
+	{"Set default policy version", []string{"iam:SetDefaultPolicyVersion"},
+		"Switching to an older, more permissive policy version can restore permissions that were removed."},
+	{"Attach managed policy to user", []string{"iam:AttachUserPolicy"},
+		"Attaching AdministratorAccess to oneself grants full access."},
+	{"Attach managed policy to group", []string{"iam:AttachGroupPolicy"},
+		"Attaching a privileged policy to a group the principal belongs to grants it those permissions."},
+	{"Attach managed policy to role", []string{"iam:AttachRolePolicy"},
+		"Attaching a privileged policy to an assumable role grants its permissions."},
+	{"Put inline user policy", []string{"iam:PutUserPolicy"},
+		"An inline policy with arbitrary permissions can be added to a user."},
+	{"Put inline group policy", []string{"iam:PutGroupPolicy"},
+		"An inline policy with arbitrary permissions can be added to a group."},
+	{"Put inline role policy", []string{"iam:PutRolePolicy"},
+		"An inline policy with arbitrary permissions can be added to a role."},
+	{"Add user to group", []string{"iam:AddUserToGroup"},
+		"Joining a privileged group grants that group's permissions."},
+	{"Update role trust policy", []string{"iam:UpdateAssumeRolePolicy", "sts:AssumeRole"},
+		"Rewriting a role's trust policy lets the principal assume a more privileged role."},
+	{"Create access key for another user", []string{"iam:CreateAccessKey"},
+		"New access keys for a privileged user give direct access to its permissions."},
+	{"Create console login for another user", []string{"iam:CreateLoginProfile"},
+		"A console password for a privileged user gives interactive access to its permissions."},
+	{"Reset console login of another user", []string{"iam:UpdateLoginProfile"},
+		"Changing a privileged user's console password gives interactive access to its permissions."},
+	{"PassRole to new Lambda function", []string{"iam:PassRole", "lambda:CreateFunction", "lambda:InvokeFunction"},
+		"A function created with a privileged role and then invoked runs arbitrary code with that role."},
+	{"PassRole to Lambda via event source", []string{"iam:PassRole", "lambda:CreateFunction", "lambda:CreateEventSourceMapping"},
+		"A function created with a privileged role can be triggered through an event source mapping."},
+	{"Update Lambda function code", []string{"lambda:UpdateFunctionCode"},
+		"Replacing the code of an existing function runs arbitrary code with the function's role."},
+	{"PassRole to EC2 instance", []string{"iam:PassRole", "ec2:RunInstances"},
+		"An instance launched with a privileged instance profile exposes the role's credentials."},
+	{"PassRole to Glue dev endpoint", []string{"iam:PassRole", "glue:CreateDevEndpoint"},
+		"A Glue development endpoint with a privileged role can be accessed over SSH to use its credentials."},
+	{"Update Glue dev endpoint", []string{"glue:UpdateDevEndpoint"},
+		"Adding an SSH key to an existing endpoint gives access to its role credentials."},
+	{"PassRole to CloudFormation", []string{"iam:PassRole", "cloudformation:CreateStack"},
+		"A stack created with a privileged service role can create arbitrary resources."},
+	{"PassRole to Data Pipeline", []string{"iam:PassRole", "datapipeline:CreatePipeline", "datapipeline:PutPipelineDefinition"},
+		"A pipeline running under a privileged role can execute arbitrary commands."},
+	{"PassRole to SageMaker notebook", []string{"iam:PassRole", "sagemaker:CreateNotebookInstance", "sagemaker:CreatePresignedNotebookInstanceUrl"},
+		"A notebook with a privileged execution role gives interactive access to its credentials."},
+	{"PassRole to CodeBuild project", []string{"iam:PassRole", "codebuild:CreateProject", "codebuild:StartBuild"},
+		"A build project with a privileged service role runs arbitrary build commands with it."},
+}
+
+func DetectPrivilegeEscalation(p *model.Policy) []model.Finding {
+	return detectPrivilegeEscalation(p, model.IdentityMapping(p))
+}
+
+func detectPrivilegeEscalation(p *model.Policy, m model.StatementMapping) []model.Finding {
+	var findings []model.Finding
+
+	for _, path := range escalationPaths {
+		grants := make(map[string][]int, len(path.actions))
+		complete := true
+		for _, action := range path.actions {
+			idx := statementsGranting(p, action)
+			if len(idx) == 0 {
+				complete = false
+				break
+			}
+			grants[action] = idx
+		}
+		if !complete {
+			continue
+		}
+
+		var contributing []int
+		evidence := make([]string, 0, len(path.actions))
+		for _, action := range path.actions {
+			contributing = append(contributing, grants[action]...)
+			evidence = append(evidence, fmt.Sprintf("%s (statement %s)", action, joinRefs(m, grants[action])))
+		}
+		contributing = uniqueSorted(contributing)
+
+		// Action "*" already gets its own wildcard finding; listing every
+		// escalation path it implies would only bury it.
+		if allFullWildcard(p, contributing) {
+			continue
+		}
+
+		findings = append(findings, model.Finding{
+			Severity:    model.SeverityHigh,
+			Title:       "Privilege escalation: " + path.name,
+			Explanation: path.explanation + " Resource scoping and conditions are not taken into account.",
+			Evidence:    "Granted: " + strings.Join(evidence, ", "),
+			StmtIndices: contributing,
+		})
+	}
+
+	return findings
+}
+
+// statementsGranting returns the Allow statements that grant the action,
+// or nothing when an unconditional Deny on every resource removes it.
+func statementsGranting(p *model.Policy, action string) []int {
+	var result []int
+	for i, s := range p.Statement {
+		if !actionCovered(s, action) {
+			continue
+		}
+		if s.Effect == "Deny" && graph.Unrestricted(s) {
+			return nil
+		}
+		if s.Effect == "Allow" {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func actionCovered(s model.Statement, action string) bool {
+	if len(s.Action) > 0 {
+		for _, a := range s.Action {
+			if graph.Match(a, action) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(s.NotAction) > 0 {
+		for _, a := range s.NotAction {
+			if graph.Match(a, action) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func allFullWildcard(p *model.Policy, indices []int) bool {
+	for _, i := range indices {
+		if !containsWildcard(p.Statement[i].Action) {
+			return false
+		}
+	}
+	return true
+}
+
+func uniqueSorted(ints []int) []int {
+	seen := make(map[int]bool, len(ints))
+	result := make([]int, 0, len(ints))
+	for _, i := range ints {
+		if !seen[i] {
+			seen[i] = true
+			result = append(result, i)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+func joinRefs(m model.StatementMapping, indices []int) string {
+	parts := make([]string, len(indices))
+	for i, idx := range indices {
+		parts[i] = m.Ref(idx)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,263 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/condition"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+type PrincipalClass string
+
+const (
+	PrincipalNone         PrincipalClass = ""
+	PrincipalSameAccount  PrincipalClass = "same-account"
+	PrincipalService      PrincipalClass = "service"
+	PrincipalCrossAccount PrincipalClass = "cross-account"
+	PrincipalPublic       PrincipalClass = "public"
+)
+
+// Condition keys that tie a request to a known network, account or
+// organization. They are only counted when the operator requires the key to
+// match, so Null, ...IfExists and negated checks do not qualify.
+var mitigatingKeys = []string{
+	"aws:SourceAccount",
+	"aws:SourceArn",
+	"aws:SourceOrgID",
+	"aws:PrincipalAccount",
+	"aws:PrincipalArn",
+	"aws:PrincipalOrgID",
+	"aws:SourceVpce",
+	"aws:SourceVpc",
+	"aws:SourceIp",
+}
+
+var serviceMitigatingKeys = []string{
+	"aws:SourceAccount",
+	"aws:SourceArn",
+	"aws:SourceOrgID",
+}
+
+// ClassifyPrincipal returns the widest audience an Allow statement grants to.
+// owner is the account the policy's resources live in; when it is empty every
+// account principal is treated as cross-account.
+func ClassifyPrincipal(s model.Statement, owner string) PrincipalClass {
+	if s.Effect != "Allow" {
+		return PrincipalNone
+	}
+	if s.NotPrincipal != nil {
+		return PrincipalPublic
+	}
+	if s.Principal == nil {
+		return PrincipalNone
+	}
+	if s.Principal.Wildcard {
+		return PrincipalPublic
+	}
+
+	class := PrincipalNone
+	for typ, ids := range s.Principal.Members {
+		for _, id := range ids {
+			class = wider(class, classifyMember(typ, id, owner))
+		}
+	}
+	return class
+}
+
+// classifyMember leaves principals with template placeholders unclassified:
+// ${AWS::AccountId} or a Terraform reference resolve to an account only at
+// deploy time.
+func classifyMember(typ, id, owner string) PrincipalClass {
+	switch strings.ToLower(typ) {
+	case "service":
+		return PrincipalService
+	case "canonicaluser":
+		return PrincipalCrossAccount
+	case "aws":
+		acct := arn.Account(id)
+		if arn.Unresolved(acct) || (acct == "" && arn.Unresolved(id)) {
+			return PrincipalNone
+		}
+		if acct == "" || strings.ContainsAny(acct, "*?") {
+			return PrincipalPublic
+		}
+		if owner != "" && acct == owner {
+			return PrincipalSameAccount
+		}
+		return PrincipalCrossAccount
+	}
+	return PrincipalNone
+}
+
+var classRank = map[PrincipalClass]int{
+	PrincipalNone:         0,
+	PrincipalSameAccount:  1,
+	PrincipalService:      2,
+	PrincipalCrossAccount: 3,
+	PrincipalPublic:       4,
+}
+
+func wider(a, b PrincipalClass) PrincipalClass {
+	if classRank[b] > classRank[a] {
+		return b
+	}
+	return a
+}
+
+func DetectPublicAccess(p *model.Policy) []model.Finding {
+	return detectPublicAccess(p, model.IdentityMapping(p))
+}
+
+func detectPublicAccess(p *model.Policy, m model.StatementMapping) []model.Finding {
+	var findings []model.Finding
+	owner := resourceOwner(p)
+
+	for i, s := range p.Statement {
+		switch ClassifyPrincipal(s, owner) {
+		case PrincipalPublic:
+			f := model.Finding{
+				Severity:    model.SeverityHigh,
+				Title:       "Public access",
+				Explanation: "The statement allows any AWS principal, including anonymous callers, unless a condition restricts the source account, organization or network.",
+				Evidence:    fmt.Sprintf("Statement %s allows %s", m.Ref(i), describePrincipal(s)),
+				StmtIndices: []int{i},
+			}
+			if keys := restrictingKeys(s.Condition, mitigatingKeys); len(keys) > 0 {
+				f.Severity = model.SeverityMedium
+				f.Evidence += "; restricted by " + strings.Join(keys, ", ")
+			}
+			findings = append(findings, f)
+
+		case PrincipalCrossAccount:
+			accounts := foreignAccounts(s.Principal, owner)
+			f := model.Finding{
+				Severity:    model.SeverityMedium,
+				Title:       "Cross-account access",
+				Explanation: "The statement grants access to principals in another AWS account.",
+				Evidence:    fmt.Sprintf("Statement %s allows account %s", m.Ref(i), strings.Join(accounts, ", ")),
+				StmtIndices: []int{i},
+			}
+			if owner == "" {
+				f.Severity = model.SeverityLow
+				f.Explanation = "The statement grants access to account principals, and the resource ARNs do not say which account owns the resource, so they may be external."
+			}
+			if keys := restrictingKeys(s.Condition, mitigatingKeys); len(keys) > 0 {
+				f.Severity = model.SeverityLow
+				f.Evidence += "; restricted by " + strings.Join(keys, ", ")
+			}
+			findings = append(findings, f)
+
+		case PrincipalService:
+			if len(restrictingKeys(s.Condition, serviceMitigatingKeys)) > 0 {
+				continue
+			}
+			findings = append(findings, model.Finding{
+				Severity:    model.SeverityLow,
+				Title:       "Service principal without source restriction",
+				Explanation: "Without aws:SourceAccount or aws:SourceArn, the service can act on behalf of resources in any account (confused deputy).",
+				Evidence:    fmt.Sprintf("Statement %s allows %s", m.Ref(i), describePrincipal(s)),
+				StmtIndices: []int{i},
+			})
+		}
+	}
+
+	return findings
+}
+
+// resourceOwner returns the account of the policy's resources when all ARNs
+// that carry an account agree on it.
+func resourceOwner(p *model.Policy) string {
+	owner := ""
+	for _, s := range p.Statement {
+		for _, r := range s.Resource {
+			acct := arn.Account(r)
+			if acct == "" || strings.ContainsAny(acct, "*?") || arn.Unresolved(acct) {
+				continue
+			}
+			if owner != "" && owner != acct {
+				return ""
+			}
+			owner = acct
+		}
+	}
+	return owner
+}
+
+func foreignAccounts(pr *model.Principal, owner string) []string {
+	seen := make(map[string]bool)
+	for typ, ids := range pr.Members {
+		for _, id := range ids {
+			if classifyMember(typ, id, owner) != PrincipalCrossAccount {
+				continue
+			}
+			if acct := arn.Account(id); acct != "" {
+				seen[acct] = true
+			} else {
+				seen[id] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(seen))
+	for a := range seen {
+		result = append(result, a)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func describePrincipal(s model.Statement) string {
+	if s.NotPrincipal != nil {
+		return "every principal except those in NotPrincipal"
+	}
+	if s.Principal.Wildcard {
+		return `Principal "*"`
+	}
+	types := make([]string, 0, len(s.Principal.Members))
+	for typ := range s.Principal.Members {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0, len(types))
+	for _, typ := range types {
+		parts = append(parts, fmt.Sprintf("%s %s", typ, strings.Join(s.Principal.Members[typ], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func restrictingKeys(c model.Condition, keys []string) []string {
+	var found []string
+	for _, k := range keys {
+		if conditionRestricts(c, k) {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+func conditionRestricts(c model.Condition, key string) bool {
+	for opName, block := range c {
+		if !requiresKey(opName) {
+			continue
+		}
+		for k := range block {
+			if strings.EqualFold(k, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiresKey reports whether an operator only matches requests that carry
+// its key with one of the listed values. Null, ...IfExists and ForAllValues
+// are satisfied by a missing key, and negated operators by any other value.
+func requiresKey(opName string) bool {
+	op, err := condition.ParseOperator(opName)
+	if err != nil {
+		return false
+	}
+	return op.Base != "Null" && !op.IfExists && !op.Negated() && op.Qualifier != condition.QualifierForAllValues
+}
@@ -8,17 +8,35 @@ import (
 )
 
 func DetectRedundant(p *model.Policy) []model.Finding {
-	return detectRedundantFromGraph(graph.Build(p))
+	return detectRedundantFromGraph(graph.Build(p), model.IdentityMapping(p))
 }
 
-func detectRedundantFromGraph(g *graph.Graph) []model.Finding {
+func detectRedundantFromGraph(g *graph.Graph, m model.StatementMapping) []model.Finding {
 	var findings []model.Finding
 	for _, e := range g.EdgesOfType(graph.Redundant) {
 		findings = append(findings, model.Finding{
 			Severity:    model.SeverityMedium,
 			Title:       "Redundant statements",
 			Explanation: "Two statements are identical and one can be removed.",
-			Evidence:    fmt.Sprintf("Statements %d and %d are identical", e.From, e.To),
+			Evidence:    fmt.Sprintf("Statements %s and %s are identical", m.Ref(e.From), m.Ref(e.To)),
+			StmtIndices: []int{e.From, e.To},
+		})
+	}
+	return findings
+}
+
+func DetectSubsumed(p *model.Policy) []model.Finding {
+	return detectSubsumedFromGraph(graph.Build(p), model.IdentityMapping(p))
+}
+
+func detectSubsumedFromGraph(g *graph.Graph, m model.StatementMapping) []model.Finding {
+	var findings []model.Finding
+	for _, e := range g.EdgesOfType(graph.Subsumes) {
+		findings = append(findings, model.Finding{
+			Severity:    model.SeverityMedium,
+			Title:       "Subsumed statement",
+			Explanation: "Everything one statement grants or denies is already covered by another statement with the same effect, so it can be removed.",
+			Evidence:    fmt.Sprintf("Statement %s covers statement %s", m.Ref(e.From), m.Ref(e.To)),
 			StmtIndices: []int{e.From, e.To},
 		})
 	}
@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Context is what a rule gets to look at. The graph is built once per
+// analysis and shared by every rule. Mapping relates statements of Policy to
+// the document the user wrote; rules should name statements with Mapping.Ref
+// in their evidence.
+type Context struct {
+	Policy  *model.Policy
+	Graph   *graph.Graph
+	Mapping model.StatementMapping
+
+	// Account owns the policy when it is known, such as the account of
+	// the role a trust policy belongs to.
+	Account string
+}
+
+// NewContext prepares a context for p. A nil mapping means p was not
+// reordered.
+func NewContext(p *model.Policy, mapping model.StatementMapping) *Context {
+	return NewContextWithGraph(p, graph.Build(p), mapping)
+}
+
+// NewContextWithGraph is NewContext with the graph of p already built.
+func NewContextWithGraph(p *model.Policy, g *graph.Graph, mapping model.StatementMapping) *Context {
+	if mapping == nil {
+		mapping = model.IdentityMapping(p)
+	}
+	return &Context{Policy: p, Graph: g, Mapping: mapping}
+}
+
+type Rule interface {
+	ID() string
+	Description() string
+	DefaultSeverity() model.Severity
+	Check(ctx *Context) []model.Finding
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Rule
+	registered = make(map[string]bool)
+)
+
+// Register adds a rule to the set run by Analyze. It is meant to be called
+// from init and panics on an empty or duplicate ID.
+func Register(r Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	id := r.ID()
+	if id == "" {
+		panic("analyzer: rule with empty ID")
+	}
+	if registered[id] {
+		panic(fmt.Sprintf("analyzer: rule %q registered twice", id))
+	}
+	registered[id] = true
+	registry = append(registry, r)
+}
+
+// Rules returns the registered rules in registration order.
+func Rules() []Rule {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Rule(nil), registry...)
+}
+
+func LookupRule(id string) (Rule, bool) {
+	for _, r := range Rules() {
+		if r.ID() == id {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+type funcRule struct {
+	id          string
+	description string
+	severity    model.Severity
+	check       func(ctx *Context) []model.Finding
+}
+
+func (r funcRule) ID() string                         { return r.id }
+func (r funcRule) Description() string                { return r.description }
+func (r funcRule) DefaultSeverity() model.Severity    { return r.severity }
+func (r funcRule) Check(ctx *Context) []model.Finding { return r.check(ctx) }
+
+// NewRule wraps a check function as a Rule.
+func NewRule(id, description string, severity model.Severity, check func(ctx *Context) []model.Finding) Rule {
+	return funcRule{id: id, description: description, severity: severity, check: check}
+}
+
+func policyRule(id, description string, severity model.Severity, detect func(*model.Policy, model.StatementMapping) []model.Finding) Rule {
+	return NewRule(id, description, severity, func(ctx *Context) []model.Finding {
+		return detect(ctx.Policy, ctx.Mapping)
+	})
+}
+
+func init() {
+	Register(NewRule("redundant-statement", "Statement is identical to another statement", model.SeverityMedium,
+		func(ctx *Context) []model.Finding { return detectRedundantFromGraph(ctx.Graph, ctx.Mapping) }))
+	Register(NewRule("subsumed-statement", "Statement is fully covered by another statement with the same effect", model.SeverityMedium,
+		func(ctx *Context) []model.Finding { return detectSubsumedFromGraph(ctx.Graph, ctx.Mapping) }))
+	Register(NewRule("mergeable-statements", "Statements share actions or resources and can be combined", model.SeverityLow,
+		func(ctx *Context) []model.Finding { return detectMergeCandidatesFromGraph(ctx.Graph, ctx.Mapping) }))
+	Register(policyRule("wildcard", "Action or Resource is a bare wildcard", model.SeverityHigh, detectWildcardOveruse))
+	Register(policyRule("negative-element", "Statement uses NotAction or NotResource", model.SeverityMedium, detectNegativeElements))
+	Register(policyRule("invalid-condition", "Condition operator or value is malformed", model.SeverityMedium, detectInvalidConditions))
+	Register(policyRule("unknown-action", "Action is not in the action catalog", model.SeverityMedium,
+		func(p *model.Policy, m model.StatementMapping) []model.Finding {
+			return detectUnknownActions(p, m, catalog.Default())
+		}))
+	Register(policyRule("privilege-escalation", "Granted actions form a known privilege-escalation path", model.SeverityHigh, detectPrivilegeEscalation))
+	Register(policyRule("public-access", "Resource policy grants public, cross-account or unrestricted service access", model.SeverityHigh,
+		func(p *model.Policy, m model.StatementMapping) []model.Finding {
+			if IsTrustPolicy(p) {
+				return nil
+			}
+			return detectPublicAccess(p, m)
+		}))
+	Register(NewRule("trust-policy", "Role trust policy admits unrestricted external principals", model.SeverityHigh,
+		func(ctx *Context) []model.Finding {
+			if !IsTrustPolicy(ctx.Policy) {
+				return nil
+			}
+			return detectTrustPolicyIssues(ctx.Policy, ctx.Mapping, ctx.Account)
+		}))
+	Register(NewRule("dead-statement", "Allow statement is entirely overridden by unconditional Deny statements", model.SeverityMedium,
+		func(ctx *Context) []model.Finding { return detectDeadStatementsFromGraph(ctx.Graph, ctx.Mapping) }))
+	Register(NewRule("deny-allow-overlap", "Deny statement overlaps an Allow statement", model.SeverityHigh,
+		func(ctx *Context) []model.Finding {
+			return detectDenyAllowOverlapFromGraph(ctx.Graph, ctx.Policy, ctx.Mapping)
+		}))
+}
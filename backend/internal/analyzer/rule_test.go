@@ -0,0 +1,99 @@
+package analyzer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// A third-party style rule; it only fires on statements tagged with its Sid so
+// it stays out of the way of other tests.
+func init() {
+	analyzer.Register(analyzer.NewRule("test-sid-marker", "Statement carries the test marker", model.SeverityLow,
+		func(ctx *analyzer.Context) []model.Finding {
+			var findings []model.Finding
+			for i, s := range ctx.Policy.Statement {
+				if s.Sid == "TestMarker" {
+					findings = append(findings, model.Finding{Severity: model.SeverityLow, Title: "Marker", StmtIndices: []int{i}})
+				}
+			}
+			return findings
+		}))
+}
+
+func markedPolicy() *model.Policy {
+	return &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Sid: "TestMarker", Effect: "Allow", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+}
+
+func TestRegister_ExternalRuleRuns(t *testing.T) {
+	findings := analyzer.Analyze(markedPolicy())
+
+	ruleIDs := make(map[string]bool)
+	for _, f := range findings {
+		if f.RuleID == "" {
+			t.Errorf("finding %q has no rule ID", f.Title)
+		}
+		ruleIDs[f.RuleID] = true
+	}
+	if !ruleIDs["test-sid-marker"] || !ruleIDs["wildcard"] {
+		t.Errorf("expected findings from test-sid-marker and wildcard, got %v", ruleIDs)
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate rule ID")
+		}
+	}()
+	analyzer.Register(analyzer.NewRule("wildcard", "", model.SeverityLow, nil))
+}
+
+func TestAnalyzeWithConfig(t *testing.T) {
+	cfg, err := analyzer.ParseConfig([]byte(`{
+		"rules": {
+			"test-sid-marker": {"enabled": false},
+			"wildcard": {"severity": "low"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	findings := analyzer.AnalyzeWithConfig(markedPolicy(), cfg)
+	sawWildcard := false
+	for _, f := range findings {
+		switch f.RuleID {
+		case "test-sid-marker":
+			t.Error("disabled rule produced a finding")
+		case "wildcard":
+			sawWildcard = true
+			if f.Severity != model.SeverityLow {
+				t.Errorf("expected overridden severity low, got %s", f.Severity)
+			}
+		}
+	}
+	if !sawWildcard {
+		t.Error("expected a wildcard finding")
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	inputs := []string{
+		`{"rules": {"no-such-rule": {"enabled": false}}}`,
+		`{"rules": {"wildcard": {"severity": "critical"}}}`,
+		`{"rules": [}`,
+	}
+	for _, in := range inputs {
+		if _, err := analyzer.ParseConfig([]byte(in)); !errors.Is(err, analyzer.ErrInvalidConfig) {
+			t.Errorf("ParseConfig(%s): expected ErrInvalidConfig, got %v", in, err)
+		}
+	}
+}
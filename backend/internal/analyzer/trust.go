@@ -0,0 +1,207 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Identity providers shared by many tenants: without a sub/aud restriction,
+// anyone with an account at the provider can obtain a matching token.
+var multiTenantProviders = []string{
+	"token.actions.githubusercontent.com",
+	"cognito-identity.amazonaws.com",
+	"accounts.google.com",
+	"graph.facebook.com",
+	"www.amazon.com",
+}
+
+// IsTrustPolicy reports whether p looks like a role trust policy: statements
+// name principals instead of resources.
+func IsTrustPolicy(p *model.Policy) bool {
+	hasPrincipal := false
+	for _, s := range p.Statement {
+		if len(s.Resource) > 0 || len(s.NotResource) > 0 {
+			return false
+		}
+		if s.Principal != nil || s.NotPrincipal != nil {
+			hasPrincipal = true
+		}
+	}
+	return hasPrincipal
+}
+
+func DetectTrustPolicyIssues(p *model.Policy) []model.Finding {
+	return detectTrustPolicyIssues(p, model.IdentityMapping(p), "")
+}
+
+// detectTrustPolicyIssues checks the trust policy of a role in account own,
+// which may be "" when the account is not known.
+func detectTrustPolicyIssues(p *model.Policy, m model.StatementMapping, own string) []model.Finding {
+	var findings []model.Finding
+
+	for i, s := range p.Statement {
+		if s.Effect != "Allow" {
+			continue
+		}
+
+		if s.NotPrincipal != nil || (s.Principal != nil && trustsAnyone(s.Principal)) {
+			if !actionCovered(s, "sts:AssumeRole") && !actionCovered(s, "sts:AssumeRoleWithWebIdentity") && !actionCovered(s, "sts:AssumeRoleWithSAML") {
+				continue
+			}
+			f := model.Finding{
+				Severity:    model.SeverityHigh,
+				Title:       "Role trusts any principal",
+				Explanation: "Any AWS principal, in any account, can assume this role unless a condition restricts who the caller is.",
+				Evidence:    fmt.Sprintf("Statement %s allows %s", m.Ref(i), describePrincipal(s)),
+				StmtIndices: []int{i},
+			}
+			if keys := restrictingKeys(s.Condition, mitigatingKeys); len(keys) > 0 {
+				f.Severity = model.SeverityMedium
+				f.Evidence += "; restricted by " + strings.Join(keys, ", ")
+			}
+			findings = append(findings, f)
+			continue
+		}
+		if s.Principal == nil {
+			continue
+		}
+
+		if actionCovered(s, "sts:AssumeRole") && !conditionRestricts(s.Condition, "sts:ExternalId") {
+			if accounts := trustedAccounts(s.Principal, own); len(accounts) > 0 {
+				findings = append(findings, model.Finding{
+					Severity:    model.SeverityMedium,
+					Title:       "Cross-account trust without ExternalId",
+					Explanation: "Principals in other accounts can assume this role without presenting sts:ExternalId, which leaves third-party integrations open to the confused deputy problem.",
+					Evidence:    fmt.Sprintf("Statement %s trusts account %s", m.Ref(i), strings.Join(accounts, ", ")),
+					StmtIndices: []int{i},
+				})
+			}
+		}
+
+		for _, provider := range federatedProviders(s.Principal) {
+			action := "sts:AssumeRoleWithWebIdentity"
+			required := []string{provider + ":aud", provider + ":sub"}
+			if strings.Contains(provider, "saml-provider/") {
+				action = "sts:AssumeRoleWithSAML"
+				required = []string{"SAML:aud"}
+			}
+			if !actionCovered(s, action) {
+				continue
+			}
+
+			var missing []string
+			for _, key := range required {
+				if !conditionPins(s.Condition, key) {
+					missing = append(missing, key)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+
+			severity := model.SeverityMedium
+			for _, mt := range multiTenantProviders {
+				if provider == mt {
+					severity = model.SeverityHigh
+				}
+			}
+			findings = append(findings, model.Finding{
+				Severity:    severity,
+				Title:       "Federated trust without audience or subject restriction",
+				Explanation: "Tokens from this identity provider are accepted without checking who they were issued to, so identities outside your organization may assume the role.",
+				Evidence:    fmt.Sprintf("Statement %s trusts %s without %s", m.Ref(i), provider, strings.Join(missing, ", ")),
+				StmtIndices: []int{i},
+			})
+		}
+	}
+
+	return findings
+}
+
+func trustsAnyone(pr *model.Principal) bool {
+	if pr.Wildcard {
+		return true
+	}
+	for typ, ids := range pr.Members {
+		for _, id := range ids {
+			if classifyMember(typ, id, "") == PrincipalPublic {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trustedAccounts returns the account IDs named in Principal.AWS other than
+// own.
+func trustedAccounts(pr *model.Principal, own string) []string {
+	var accounts []string
+	for typ, ids := range pr.Members {
+		if !strings.EqualFold(typ, "AWS") {
+			continue
+		}
+		for _, id := range ids {
+			if acct := arn.Account(id); acct != "" && acct != own && !arn.Unresolved(acct) {
+				accounts = append(accounts, acct)
+			}
+		}
+	}
+	return uniqueSortedStrings(accounts)
+}
+
+// federatedProviders returns the provider names used as condition key
+// prefixes, e.g. token.actions.githubusercontent.com for
+// arn:aws:iam::111122223333:oidc-provider/token.actions.githubusercontent.com.
+func federatedProviders(pr *model.Principal) []string {
+	var providers []string
+	for typ, ids := range pr.Members {
+		if !strings.EqualFold(typ, "Federated") {
+			continue
+		}
+		for _, id := range ids {
+			if _, after, ok := strings.Cut(id, ":oidc-provider/"); ok {
+				id = after
+			}
+			providers = append(providers, id)
+		}
+	}
+	return uniqueSortedStrings(providers)
+}
+
+// conditionPins is like conditionRestricts but also ignores keys whose only
+// value is a bare "*", which some templates use as a placeholder.
+func conditionPins(c model.Condition, key string) bool {
+	for opName, block := range c {
+		if !requiresKey(opName) {
+			continue
+		}
+		for k, v := range block {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			for _, val := range v.Strings() {
+				if val != "*" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func uniqueSortedStrings(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	result := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
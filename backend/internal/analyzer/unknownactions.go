@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func DetectUnknownActions(p *model.Policy) []model.Finding {
+	return detectUnknownActions(p, model.IdentityMapping(p), catalog.Default())
+}
+
+// The catalog does not list every service or action, so an entry is only
+// reported when a close catalog action suggests a typo, or when its service
+// is marked complete.
+func detectUnknownActions(p *model.Policy, m model.StatementMapping, cat *catalog.Catalog) []model.Finding {
+	var findings []model.Finding
+
+	for i, s := range p.Statement {
+		for _, field := range []struct {
+			name     string
+			patterns []string
+		}{{"Action", s.Action}, {"NotAction", s.NotAction}} {
+			for _, pattern := range field.patterns {
+				f, ok := unknownActionFinding(cat, i, m.Ref(i), s.Effect, field.name, pattern)
+				if ok {
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+func unknownActionFinding(cat *catalog.Catalog, idx int, ref, effect, field, pattern string) (model.Finding, bool) {
+	if pattern == "*" || len(cat.Expand(pattern)) > 0 {
+		return model.Finding{}, false
+	}
+
+	prefix, _, _ := strings.Cut(pattern, ":")
+
+	severity := model.SeverityMedium
+	impact := "The statement silently grants nothing for this entry."
+	if effect == "Deny" {
+		severity = model.SeverityHigh
+		impact = "The Deny silently does not apply, leaving the intended action allowed."
+	}
+
+	svc, known := cat.Service(prefix)
+	complete := known && svc.Complete
+
+	if strings.ContainsAny(pattern, "*?") {
+		if !complete {
+			return model.Finding{}, false
+		}
+		return model.Finding{
+			Severity:    severity,
+			Title:       "Action pattern matches nothing",
+			Explanation: "The wildcard pattern does not match any known action. " + impact,
+			Evidence:    fmt.Sprintf("Statement %s: %s %q matches no action", ref, field, pattern),
+			StmtIndices: []int{idx},
+		}, true
+	}
+
+	match, found := cat.Suggest(pattern)
+	if !found && !complete {
+		return model.Finding{}, false
+	}
+
+	evidence := fmt.Sprintf("Statement %s: %s %q is not a known action", ref, field, pattern)
+	if found {
+		evidence += fmt.Sprintf("; did you mean %q?", match.String())
+	}
+
+	return model.Finding{
+		Severity:    severity,
+		Title:       "Unknown action",
+		Explanation: "The action does not exist, most likely because of a typo. " + impact,
+		Evidence:    evidence,
+		StmtIndices: []int{idx},
+	}, true
+}
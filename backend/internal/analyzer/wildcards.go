@@ -7,6 +7,10 @@ import (
 )
 
 func DetectWildcardOveruse(p *model.Policy) []model.Finding {
+	return detectWildcardOveruse(p, model.IdentityMapping(p))
+}
+
+func detectWildcardOveruse(p *model.Policy, m model.StatementMapping) []model.Finding {
 	var findings []model.Finding
 
 	for i, s := range p.Statement {
@@ -18,7 +22,7 @@ func DetectWildcardOveruse(p *model.Policy) []model.Finding {
 				Severity:    model.SeverityHigh,
 				Title:       "Full wildcard statement",
 				Explanation: "Both Action and Resource are wildcards. This grants unrestricted access.",
-				Evidence:    fmt.Sprintf("Statement %d has Action=* and Resource=*", i),
+				Evidence:    fmt.Sprintf("Statement %s has Action=* and Resource=*", m.Ref(i)),
 				StmtIndices: []int{i},
 			})
 		} else if wildcardAction {
@@ -26,7 +30,7 @@ func DetectWildcardOveruse(p *model.Policy) []model.Finding {
 				Severity:    model.SeverityMedium,
 				Title:       "Wildcard action",
 				Explanation: "Action is a wildcard. This grants all actions on the specified resources.",
-				Evidence:    fmt.Sprintf("Statement %d has Action=*", i),
+				Evidence:    fmt.Sprintf("Statement %s has Action=*", m.Ref(i)),
 				StmtIndices: []int{i},
 			})
 		} else if wildcardResource {
@@ -34,7 +38,7 @@ func DetectWildcardOveruse(p *model.Policy) []model.Finding {
 				Severity:    model.SeverityMedium,
 				Title:       "Wildcard resource",
 				Explanation: "Resource is a wildcard. The specified actions apply to all resources.",
-				Evidence:    fmt.Sprintf("Statement %d has Resource=*", i),
+				Evidence:    fmt.Sprintf("Statement %s has Resource=*", m.Ref(i)),
 				StmtIndices: []int{i},
 			})
 		}
@@ -0,0 +1,64 @@
+package arn
+
+import "strings"
+
+// Account returns the account of an account ID or an ARN, or "" when id
+// carries none. The account may be an Unresolved placeholder.
+func Account(id string) string {
+	if IsAccountID(id) {
+		return id
+	}
+	parts := split(id, 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
+// Unresolved reports whether s holds a ${...} placeholder that templates leave
+// for values only known at deploy time, such as ${AWS::AccountId} or
+// ${var.account_id}.
+func Unresolved(s string) bool {
+	return strings.Contains(s, "${")
+}
+
+// split is strings.SplitN on the colons outside ${...} placeholders.
+func split(s string, n int) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s) && len(parts) < n-1; i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case s[i] == ':' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Principal writes an AWS principal the way it is compared: an account ID
+// stands for the account's root user, so it becomes that root ARN. Anything
+// else is returned as it is.
+func Principal(id string) string {
+	if IsAccountID(id) {
+		return "arn:aws:iam::" + id + ":root"
+	}
+	return id
+}
+
+func IsAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
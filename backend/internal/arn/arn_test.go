@@ -0,0 +1,47 @@
+package arn_test
+
+import (
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+)
+
+func TestAccount(t *testing.T) {
+	tests := []struct {
+		id, want string
+	}{
+		{"123456789012", "123456789012"},
+		{"arn:aws:iam::123456789012:root", "123456789012"},
+		{"arn:aws:s3:::bucket/*", ""},
+		{"arn:aws:iam::*:role/x", "*"},
+		{"12345678901", ""},
+		{"ec2.amazonaws.com", ""},
+		{"arn:aws:iam::${AWS::AccountId}:root", "${AWS::AccountId}"},
+		{"arn:aws:iam::${var.account}:role/x", "${var.account}"},
+		{"arn:aws:s3:::${Bucket}/${aws:username}/*", ""},
+		{"${Role.Arn}", ""},
+	}
+
+	for _, tt := range tests {
+		if got := arn.Account(tt.id); got != tt.want {
+			t.Errorf("Account(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	tests := []struct {
+		id, want string
+	}{
+		{"123456789012", "arn:aws:iam::123456789012:root"},
+		{"arn:aws:iam::123456789012:root", "arn:aws:iam::123456789012:root"},
+		{"arn:aws:iam::123456789012:role/app", "arn:aws:iam::123456789012:role/app"},
+		{"*", "*"},
+	}
+
+	for _, tt := range tests {
+		if got := arn.Principal(tt.id); got != tt.want {
+			t.Errorf("Principal(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
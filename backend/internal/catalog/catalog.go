@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+)
+
+const (
+	AccessList        = "List"
+	AccessRead        = "Read"
+	AccessWrite       = "Write"
+	AccessPermissions = "Permissions management"
+	AccessTagging     = "Tagging"
+)
+
+var AccessLevels = []string{AccessList, AccessRead, AccessWrite, AccessPermissions, AccessTagging}
+
+var ErrInvalidCatalog = errors.New("invalid action catalog")
+
+//go:embed data/aws.json
+var embedded []byte
+
+type Action struct {
+	Name          string   `json:"name"`
+	AccessLevel   string   `json:"accessLevel"`
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+}
+
+// Service lists the actions of one AWS service. Complete marks the list as
+// exhaustive; only then does a missing action mean it does not exist.
+type Service struct {
+	Prefix        string   `json:"prefix"`
+	Name          string   `json:"name"`
+	ConditionKeys []string `json:"conditionKeys,omitempty"`
+	Actions       []Action `json:"actions"`
+	Complete      bool     `json:"complete,omitempty"`
+}
+
+// Catalog is a snapshot of AWS services and actions. The embedded one is
+// partial: it covers the commonly used services and their common actions, so
+// an action missing from it may still exist. Complete marks a catalog that
+// lists every service.
+type Catalog struct {
+	Version  string    `json:"version"`
+	Complete bool      `json:"complete,omitempty"`
+	Services []Service `json:"services"`
+
+	byPrefix map[string]*Service
+	byAction map[string]QualifiedAction
+}
+
+// QualifiedAction is a catalog action together with its service prefix, e.g. s3:GetObject.
+type QualifiedAction struct {
+	Service string
+	Action
+}
+
+func (a QualifiedAction) String() string {
+	return a.Service + ":" + a.Name
+}
+
+func Parse(data []byte) (*Catalog, error) {
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	if c.Version == "" {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidCatalog)
+	}
+
+	c.byPrefix = make(map[string]*Service, len(c.Services))
+	c.byAction = make(map[string]QualifiedAction)
+	for i := range c.Services {
+		svc := &c.Services[i]
+		prefix := strings.ToLower(svc.Prefix)
+		if prefix == "" {
+			return nil, fmt.Errorf("%w: service %d has no prefix", ErrInvalidCatalog, i)
+		}
+		if _, dup := c.byPrefix[prefix]; dup {
+			return nil, fmt.Errorf("%w: duplicate service %q", ErrInvalidCatalog, svc.Prefix)
+		}
+		c.byPrefix[prefix] = svc
+
+		for _, a := range svc.Actions {
+			if !validAccessLevel(a.AccessLevel) {
+				return nil, fmt.Errorf("%w: %s:%s has unknown access level %q", ErrInvalidCatalog, svc.Prefix, a.Name, a.AccessLevel)
+			}
+			c.byAction[prefix+":"+strings.ToLower(a.Name)] = QualifiedAction{Service: svc.Prefix, Action: a}
+		}
+	}
+
+	return &c, nil
+}
+
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+var current atomic.Pointer[Catalog]
+
+// Default returns the catalog installed with SetDefault, falling back to the
+// one embedded in the binary.
+func Default() *Catalog {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	c, err := Parse(embedded)
+	if err != nil {
+		panic("embedded catalog: " + err.Error())
+	}
+	current.CompareAndSwap(nil, c)
+	return current.Load()
+}
+
+func SetDefault(c *Catalog) {
+	current.Store(c)
+}
+
+// Partial reports whether services or actions may be missing from the
+// catalog, making expansions and counts lower bounds.
+func (c *Catalog) Partial() bool {
+	if !c.Complete {
+		return true
+	}
+	for _, svc := range c.Services {
+		if !svc.Complete {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Catalog) Service(prefix string) (*Service, bool) {
+	svc, ok := c.byPrefix[strings.ToLower(prefix)]
+	return svc, ok
+}
+
+func (c *Catalog) Lookup(action string) (QualifiedAction, bool) {
+	a, ok := c.byAction[strings.ToLower(action)]
+	return a, ok
+}
+
+// Expand returns every catalog action matched by an IAM action pattern, sorted by name.
+func (c *Catalog) Expand(pattern string) []QualifiedAction {
+	if !strings.ContainsAny(pattern, "*?") {
+		if a, ok := c.Lookup(pattern); ok {
+			return []QualifiedAction{a}
+		}
+		return nil
+	}
+
+	var result []QualifiedAction
+	for _, a := range c.byAction {
+		if graph.Match(pattern, a.String()) {
+			result = append(result, a)
+		}
+	}
+	sortActions(result)
+	return result
+}
+
+func (c *Catalog) All() []QualifiedAction {
+	result := make([]QualifiedAction, 0, len(c.byAction))
+	for _, a := range c.byAction {
+		result = append(result, a)
+	}
+	sortActions(result)
+	return result
+}
+
+func sortActions(actions []QualifiedAction) {
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].String() < actions[j].String()
+	})
+}
+
+func validAccessLevel(level string) bool {
+	for _, l := range AccessLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package catalog_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+)
+
+func TestDefault_Embedded(t *testing.T) {
+	c := catalog.Default()
+	if c.Version == "" {
+		t.Fatal("expected embedded catalog to have a version")
+	}
+
+	a, ok := c.Lookup("S3:getobject")
+	if !ok {
+		t.Fatal("expected s3:GetObject in catalog")
+	}
+	if a.String() != "s3:GetObject" || a.AccessLevel != catalog.AccessRead {
+		t.Errorf("unexpected action %+v", a)
+	}
+
+	if _, ok := c.Service("iam"); !ok {
+		t.Error("expected iam service in catalog")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	c := catalog.Default()
+
+	gets := c.Expand("s3:GetObject*")
+	if len(gets) < 3 {
+		t.Fatalf("expected several s3:GetObject* actions, got %d", len(gets))
+	}
+	for i := 1; i < len(gets); i++ {
+		if gets[i-1].String() >= gets[i].String() {
+			t.Errorf("expected sorted results, got %s before %s", gets[i-1], gets[i])
+		}
+	}
+
+	if got := c.Expand("s3:GetObjet"); len(got) != 0 {
+		t.Errorf("expected no match for typo, got %v", got)
+	}
+	if got := c.Expand("*"); len(got) != len(c.All()) {
+		t.Errorf("expected * to expand to every action, got %d of %d", len(got), len(c.All()))
+	}
+}
+
+func TestLoad_FromDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	data := `{"version": "test-1", "services": [{"prefix": "demo", "name": "Demo", "actions": [
+		{"name": "ReadThing", "accessLevel": "Read"},
+		{"name": "TagThing", "accessLevel": "Tagging"}
+	]}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := catalog.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Version != "test-1" {
+		t.Errorf("expected version test-1, got %s", c.Version)
+	}
+	if got := c.Expand("demo:*"); len(got) != 2 {
+		t.Errorf("expected 2 actions, got %d", len(got))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		`{not json`,
+		`{"services": []}`,
+		`{"version": "x", "services": [{"prefix": "a", "actions": [{"name": "B", "accessLevel": "Admin"}]}]}`,
+		`{"version": "x", "services": [{"prefix": "a", "actions": []}, {"prefix": "A", "actions": []}]}`,
+	}
+
+	for _, data := range tests {
+		if _, err := catalog.Parse([]byte(data)); !errors.Is(err, catalog.ErrInvalidCatalog) {
+			t.Errorf("Parse(%s): expected ErrInvalidCatalog, got %v", data, err)
+		}
+	}
+}
+
+func TestPartial(t *testing.T) {
+	if !catalog.Default().Partial() {
+		t.Error("expected the embedded catalog to be partial")
+	}
+
+	c, err := catalog.Parse([]byte(`{"version": "x", "complete": true, "services": [
+		{"prefix": "a", "complete": true, "actions": []},
+		{"prefix": "b", "actions": []}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Partial() {
+		t.Error("expected a catalog with an incomplete service to be partial")
+	}
+	c.Services[1].Complete = true
+	if c.Partial() {
+		t.Error("expected a catalog of complete services to be complete")
+	}
+}
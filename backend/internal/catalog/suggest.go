@@ -0,0 +1,53 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/textdist"
+)
+
+const maxSuggestDistance = 3
+
+// Suggest finds the closest known action for a name that is not in the
+// catalog. Only the action name is compared within a known service; for an
+// unknown prefix the same action name is looked up under close service
+// prefixes, so "lamda:InvokeFunction" still resolves.
+func (c *Catalog) Suggest(action string) (QualifiedAction, bool) {
+	prefix, name, ok := strings.Cut(action, ":")
+	if !ok || name == "" {
+		return QualifiedAction{}, false
+	}
+
+	if svc, known := c.Service(prefix); known {
+		return closestAction(svc, name)
+	}
+
+	var best QualifiedAction
+	bestDist := maxSuggestDistance + 1
+	for _, svc := range c.Services {
+		d := textdist.Distance(strings.ToLower(prefix), strings.ToLower(svc.Prefix))
+		if d > 1 || d >= bestDist {
+			continue
+		}
+		if a, found := c.Lookup(svc.Prefix + ":" + name); found {
+			best, bestDist = a, d
+		}
+	}
+	return best, bestDist <= maxSuggestDistance
+}
+
+func closestAction(svc *Service, name string) (QualifiedAction, bool) {
+	lower := strings.ToLower(name)
+	limit := min(maxSuggestDistance, max(1, len(name)/4))
+
+	var best QualifiedAction
+	bestDist := limit + 1
+	for _, a := range svc.Actions {
+		d := textdist.Distance(lower, strings.ToLower(a.Name))
+		if d < bestDist {
+			best = QualifiedAction{Service: svc.Prefix, Action: a}
+			bestDist = d
+		}
+	}
+	return best, bestDist <= limit
+}
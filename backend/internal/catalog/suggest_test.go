@@ -0,0 +1,36 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+)
+
+func TestSuggest(t *testing.T) {
+	c := catalog.Default()
+
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"s3:GetObjet", "s3:GetObject", true},
+		{"s3:getobjetc", "s3:GetObject", true},
+		{"iam:PasRole", "iam:PassRole", true},
+		{"lamda:InvokeFunction", "lambda:InvokeFunction", true},
+		{"s3:CompletelyDifferent", "", false},
+		{"notaservice:Foo", "", false},
+		{"s3", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := c.Suggest(tt.in)
+		if ok != tt.ok {
+			t.Errorf("Suggest(%q) ok = %v, want %v (got %s)", tt.in, ok, tt.ok, got)
+			continue
+		}
+		if ok && got.String() != tt.want {
+			t.Errorf("Suggest(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package cfn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("invalid CloudFormation template")
+	ErrNotTemplate     = errors.New("document has no Resources section")
+)
+
+// Document is one policy document found in a template. Policy is JSON with
+// intrinsic functions replaced by ${...} placeholders.
+type Document struct {
+	LogicalID    string
+	ResourceType string
+	Path         string
+	PolicyName   string
+	Policy       []byte
+}
+
+// policyProperties lists where each supported resource type keeps its policy
+// documents.
+var policyProperties = map[string][]string{
+	"AWS::IAM::Policy":        {"PolicyDocument"},
+	"AWS::IAM::ManagedPolicy": {"PolicyDocument"},
+	"AWS::IAM::Role":          {"AssumeRolePolicyDocument"},
+	"AWS::S3::BucketPolicy":   {"PolicyDocument"},
+	"AWS::SQS::QueuePolicy":   {"PolicyDocument"},
+	"AWS::KMS::Key":           {"KeyPolicy"},
+}
+
+// inlinePolicies are the resource types whose Policies property holds a list
+// of named inline policies.
+var inlinePolicies = map[string]bool{
+	"AWS::IAM::Role": true,
+}
+
+// IsTemplate reports whether raw looks like a template rather than a policy.
+func IsTemplate(raw []byte) bool {
+	v, err := decodeTemplate(raw)
+	if err != nil {
+		return false
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, hasResources := m["Resources"].(map[string]any)
+	_, hasStatement := m["Statement"]
+	return hasResources && !hasStatement
+}
+
+// Extract returns the policy documents of every supported resource in a JSON
+// or YAML template, ordered by logical ID.
+func Extract(raw []byte) ([]Document, error) {
+	v, err := decodeTemplate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	tmpl, _ := v.(map[string]any)
+	resources, ok := tmpl["Resources"].(map[string]any)
+	if !ok {
+		return nil, ErrNotTemplate
+	}
+
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var docs []Document
+	for _, id := range ids {
+		res, _ := resources[id].(map[string]any)
+		typ, _ := res["Type"].(string)
+		props, _ := res["Properties"].(map[string]any)
+		if props == nil {
+			continue
+		}
+
+		for _, prop := range policyProperties[typ] {
+			if doc, ok := props[prop]; ok {
+				d, err := document(id, typ, "Properties."+prop, doc)
+				if err != nil {
+					return nil, err
+				}
+				docs = append(docs, d)
+			}
+		}
+
+		if !inlinePolicies[typ] {
+			continue
+		}
+		list, _ := placeholders(props["Policies"]).([]any)
+		for i, item := range list {
+			p, _ := item.(map[string]any)
+			doc, ok := p["PolicyDocument"]
+			if !ok {
+				continue
+			}
+			d, err := document(id, typ, fmt.Sprintf("Properties.Policies[%d].PolicyDocument", i), doc)
+			if err != nil {
+				return nil, err
+			}
+			d.PolicyName = render(p["PolicyName"])
+			docs = append(docs, d)
+		}
+	}
+	return docs, nil
+}
+
+func document(id, typ, path string, doc any) (Document, error) {
+	d := Document{LogicalID: id, ResourceType: typ, Path: path}
+
+	// Some properties accept the document as a JSON string.
+	if s, ok := doc.(string); ok {
+		d.Policy = []byte(s)
+		return d, nil
+	}
+
+	data, err := json.Marshal(placeholders(doc))
+	if err != nil {
+		return Document{}, fmt.Errorf("%w: %s %s: %v", ErrInvalidTemplate, id, path, err)
+	}
+	d.Policy = data
+	return d, nil
+}
+
+func decodeTemplate(raw []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, errors.New("empty document")
+	}
+	d := &decoder{}
+	return d.decode(&doc)
+}
+
+// maxDecodedNodes bounds the nodes visited while expanding aliases, so a
+// small template of nested aliases cannot grow exponentially. Templates are
+// capped at 1 MB, which keeps alias-free ones well below it.
+const maxDecodedNodes = 1 << 20
+
+type decoder struct {
+	nodes int
+}
+
+// decode turns a YAML node into plain values, expanding the short form of
+// intrinsic functions (!Ref, !Sub, ...) into their long form.
+func (d *decoder) decode(n *yaml.Node) (any, error) {
+	d.nodes++
+	if d.nodes > maxDecodedNodes {
+		return nil, fmt.Errorf("line %d: aliases expand beyond %d nodes", n.Line, maxDecodedNodes)
+	}
+
+	var v any
+	switch n.Kind {
+	case yaml.DocumentNode:
+		return d.decode(n.Content[0])
+	case yaml.AliasNode:
+		return d.decode(n.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			val, err := d.decode(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = val
+		}
+		v = m
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			val, err := d.decode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, val)
+		}
+		v = list
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null", "!!bool", "!!int", "!!float":
+			if err := n.Decode(&v); err != nil {
+				return nil, err
+			}
+		default:
+			v = n.Value
+		}
+	}
+
+	if strings.HasPrefix(n.Tag, "!") && !strings.HasPrefix(n.Tag, "!!") {
+		name := strings.TrimPrefix(n.Tag, "!")
+		if name != "Ref" && name != "Condition" {
+			name = "Fn::" + name
+		}
+		if name == "Fn::GetAtt" {
+			if s, ok := v.(string); ok {
+				attr, field, _ := strings.Cut(s, ".")
+				v = []any{attr, field}
+			}
+		}
+		v = map[string]any{name: v}
+	}
+	return v, nil
+}
+
+func intrinsic(v any) (string, any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", nil, false
+	}
+	for k, arg := range m {
+		if k == "Ref" || strings.HasPrefix(k, "Fn::") {
+			return k, arg, true
+		}
+	}
+	return "", nil, false
+}
+
+// placeholders replaces intrinsic functions with strings the parser accepts.
+// Fn::If keeps its first branch and Ref AWS::NoValue removes the value.
+func placeholders(v any) any {
+	if name, arg, ok := intrinsic(v); ok {
+		if args, _ := arg.([]any); name == "Fn::If" && len(args) == 3 {
+			return placeholders(args[1])
+		}
+		return render(v)
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, val := range x {
+			if isNoValue(val) {
+				continue
+			}
+			out[k] = placeholders(val)
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(x))
+		for _, val := range x {
+			if isNoValue(val) {
+				continue
+			}
+			out = append(out, placeholders(val))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func isNoValue(v any) bool {
+	name, arg, ok := intrinsic(v)
+	return ok && name == "Ref" && arg == "AWS::NoValue"
+}
+
+// render turns a value into the string it stands for, with the parts only
+// known at deploy time written as ${...}, the same syntax Fn::Sub uses.
+func render(v any) string {
+	name, arg, ok := intrinsic(v)
+	if !ok {
+		switch x := v.(type) {
+		case nil:
+			return ""
+		case string:
+			return x
+		case []any:
+			parts := make([]string, len(x))
+			for i, item := range x {
+				parts[i] = render(item)
+			}
+			return strings.Join(parts, ",")
+		default:
+			return fmt.Sprint(x)
+		}
+	}
+
+	args, _ := arg.([]any)
+	switch name {
+	case "Ref":
+		return "${" + render(arg) + "}"
+	case "Fn::Sub":
+		if len(args) > 0 {
+			return render(args[0])
+		}
+		return render(arg)
+	case "Fn::GetAtt":
+		if len(args) > 0 {
+			parts := make([]string, len(args))
+			for i, a := range args {
+				parts[i] = render(a)
+			}
+			return "${" + strings.Join(parts, ".") + "}"
+		}
+		return "${" + render(arg) + "}"
+	case "Fn::Join":
+		if len(args) == 2 {
+			items, _ := args[1].([]any)
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = render(item)
+			}
+			return strings.Join(parts, render(args[0]))
+		}
+	case "Fn::If":
+		if len(args) == 3 {
+			return render(args[1])
+		}
+	case "Fn::ImportValue":
+		return "${Import:" + render(arg) + "}"
+	}
+	return "${" + name + "}"
+}
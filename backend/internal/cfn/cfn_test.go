@@ -0,0 +1,144 @@
+package cfn_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/cfn"
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+)
+
+const template = `AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Env:
+    Type: String
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+  BucketPolicy:
+    Type: AWS::S3::BucketPolicy
+    Properties:
+      Bucket: !Ref Bucket
+      PolicyDocument:
+        Version: 2012-10-17
+        Statement:
+          - Effect: Allow
+            Principal: "*"
+            Action: s3:GetObject
+            Resource: !Sub "arn:aws:s3:::${Bucket}/*"
+  AppRole:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Version: 2012-10-17
+        Statement:
+          - Effect: Allow
+            Principal:
+              Service: lambda.amazonaws.com
+            Action: sts:AssumeRole
+      Policies:
+        - PolicyName: !Sub "app-${Env}"
+          PolicyDocument:
+            Version: 2012-10-17
+            Statement:
+              - Effect: Allow
+                Action:
+                  - dynamodb:GetItem
+                  - !Ref AWS::NoValue
+                Resource:
+                  - !GetAtt Table.Arn
+                  - !Join ["", [!GetAtt Table.Arn, "/index/*"]]
+  Key:
+    Type: AWS::KMS::Key
+    Properties:
+      KeyPolicy:
+        Version: 2012-10-17
+        Statement:
+          - Effect: Allow
+            Principal:
+              AWS: !Sub "arn:aws:iam::${AWS::AccountId}:root"
+            Action: kms:*
+            Resource: "*"
+`
+
+func TestExtract(t *testing.T) {
+	if !cfn.IsTemplate([]byte(template)) {
+		t.Fatal("expected the template to be recognized")
+	}
+
+	docs, err := cfn.Extract([]byte(template))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, d := range docs {
+		got = append(got, d.LogicalID+" "+d.Path)
+	}
+	want := []string{
+		"AppRole Properties.AssumeRolePolicyDocument",
+		"AppRole Properties.Policies[0].PolicyDocument",
+		"BucketPolicy Properties.PolicyDocument",
+		"Key Properties.KeyPolicy",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if docs[1].PolicyName != "app-${Env}" {
+		t.Errorf("unexpected policy name %q", docs[1].PolicyName)
+	}
+
+	for _, d := range docs {
+		if _, err := parser.Parse(d.Policy); err != nil {
+			t.Errorf("%s %s does not parse: %v\n%s", d.LogicalID, d.Path, err, d.Policy)
+		}
+	}
+
+	inline, _ := parser.Parse(docs[1].Policy)
+	s := inline.Statement[0]
+	if len(s.Action) != 1 || s.Resource[0] != "${Table.Arn}" || s.Resource[1] != "${Table.Arn}/index/*" {
+		t.Errorf("unexpected placeholders: %v %v", s.Action, s.Resource)
+	}
+	bucket, _ := parser.Parse(docs[2].Policy)
+	if bucket.Statement[0].Resource[0] != "arn:aws:s3:::${Bucket}/*" {
+		t.Errorf("unexpected resource %v", bucket.Statement[0].Resource)
+	}
+}
+
+func TestExtract_JSONTemplate(t *testing.T) {
+	raw := `{"Resources": {"Queue": {"Type": "AWS::SQS::QueuePolicy", "Properties": {
+		"PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "sqs:SendMessage", "Resource": {"Fn::GetAtt": ["Q", "Arn"]}}]}}}}}`
+
+	docs, err := cfn.Extract([]byte(raw))
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("expected one document, got %v %v", docs, err)
+	}
+	p, err := parser.Parse(docs[0].Policy)
+	if err != nil || p.Statement[0].Resource[0] != "${Q.Arn}" {
+		t.Errorf("unexpected policy %s: %v", docs[0].Policy, err)
+	}
+}
+
+func TestIsTemplate_Policy(t *testing.T) {
+	if cfn.IsTemplate([]byte(`{"Version": "2012-10-17", "Statement": []}`)) {
+		t.Error("a policy is not a template")
+	}
+	if _, err := cfn.Extract([]byte(`{"Version": "2012-10-17"}`)); err == nil {
+		t.Error("expected an error without Resources")
+	}
+}
+
+func TestExtract_AliasExpansion(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Resources: {}\nMetadata:\n  a0: &a0 [x, x, x, x, x, x, x, x]\n")
+	for i := 1; i <= 10; i++ {
+		prev := fmt.Sprintf("*a%d", i-1)
+		fmt.Fprintf(&b, "  a%d: &a%d [%s, %s, %s, %s, %s, %s, %s, %s]\n", i, i, prev, prev, prev, prev, prev, prev, prev, prev)
+	}
+
+	if _, err := cfn.Extract([]byte(b.String())); !errors.Is(err, cfn.ErrInvalidTemplate) {
+		t.Errorf("expected nested aliases to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package condition
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+var (
+	ErrUnknownOperator = errors.New("unknown condition operator")
+	ErrInvalidValue    = errors.New("invalid condition value")
+)
+
+type Qualifier string
+
+const (
+	QualifierNone         Qualifier = ""
+	QualifierForAnyValue  Qualifier = "ForAnyValue"
+	QualifierForAllValues Qualifier = "ForAllValues"
+)
+
+type Operator struct {
+	Name      string
+	Base      string
+	Qualifier Qualifier
+	IfExists  bool
+	negated   bool
+	pattern   bool
+	compare   comparator
+}
+
+func ParseOperator(name string) (Operator, error) {
+	op := Operator{Name: name}
+	rest := name
+
+	if q, after, ok := strings.Cut(rest, ":"); ok {
+		switch Qualifier(q) {
+		case QualifierForAnyValue, QualifierForAllValues:
+			op.Qualifier = Qualifier(q)
+			rest = after
+		default:
+			return Operator{}, fmt.Errorf("%w: %q", ErrUnknownOperator, name)
+		}
+	}
+
+	if base, ok := strings.CutSuffix(rest, "IfExists"); ok {
+		op.IfExists = true
+		rest = base
+	}
+
+	spec, ok := operators[rest]
+	if !ok {
+		return Operator{}, fmt.Errorf("%w: %q", ErrUnknownOperator, name)
+	}
+	if rest == "Null" && (op.IfExists || op.Qualifier != QualifierNone) {
+		return Operator{}, fmt.Errorf("%w: %q", ErrUnknownOperator, name)
+	}
+
+	op.Base = rest
+	op.negated = spec.negated
+	op.pattern = spec.pattern
+	op.compare = spec.compare
+	return op, nil
+}
+
+func (op Operator) Negated() bool {
+	return op.negated
+}
+
+// Evaluate reports whether every operator/key pair in c is satisfied by ctx.
+// Blocks are ANDed together, keys within a block are ANDed and the values of a
+// single key are ORed, matching the IAM evaluation rules.
+func Evaluate(c model.Condition, ctx Context) (bool, error) {
+	for _, opName := range sortedKeys(c) {
+		op, err := ParseOperator(opName)
+		if err != nil {
+			return false, err
+		}
+		for key, want := range c[opName] {
+			ok, err := op.Evaluate(key, want.Strings(), ctx)
+			if err != nil {
+				return false, fmt.Errorf("%s %s: %w", opName, key, err)
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func (op Operator) Evaluate(key string, want []string, ctx Context) (bool, error) {
+	got, present := ctx.Lookup(key)
+
+	if op.Base == "Null" {
+		return evalNull(want, present)
+	}
+
+	want = ctx.substituteAll(want, op.pattern)
+
+	switch op.Qualifier {
+	case QualifierForAnyValue:
+		if !present {
+			return op.IfExists, nil
+		}
+		if len(got) == 0 {
+			return false, nil
+		}
+		for _, g := range got {
+			ok, err := op.matchOne(g, want)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case QualifierForAllValues:
+		if !present || len(got) == 0 {
+			return true, nil
+		}
+		for _, g := range got {
+			ok, err := op.matchOne(g, want)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	if !present {
+		return op.IfExists || op.negated, nil
+	}
+
+	for _, g := range got {
+		matched, err := op.anyValueMatches(g, want)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return !op.negated, nil
+		}
+	}
+	return op.negated, nil
+}
+
+// matchOne applies the operator, including negation, to a single context value.
+func (op Operator) matchOne(got string, want []string) (bool, error) {
+	matched, err := op.anyValueMatches(got, want)
+	if err != nil {
+		return false, err
+	}
+	return matched != op.negated, nil
+}
+
+func (op Operator) anyValueMatches(got string, want []string) (bool, error) {
+	for _, w := range want {
+		ok, err := op.compare(w, got)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func evalNull(want []string, present bool) (bool, error) {
+	for _, w := range want {
+		var absent bool
+		switch strings.ToLower(w) {
+		case "true":
+			absent = true
+		case "false":
+			absent = false
+		default:
+			return false, fmt.Errorf("%w: Null expects true or false, got %q", ErrInvalidValue, w)
+		}
+		if absent == present {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Validate checks operators and policy-side values without a request context,
+// so malformed conditions can be reported before anything is simulated.
+func Validate(c model.Condition) []error {
+	var errs []error
+	for _, opName := range sortedKeys(c) {
+		op, err := ParseOperator(opName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, key := range sortedKeys(c[opName]) {
+			for _, w := range c[opName][key].Strings() {
+				if err := op.validateValue(w); err != nil {
+					errs = append(errs, fmt.Errorf("%s %s: %w", opName, key, err))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (op Operator) validateValue(v string) error {
+	if op.Base == "Null" {
+		_, err := evalNull([]string{v}, false)
+		return err
+	}
+	if strings.Contains(v, "${") {
+		// Policy variables are only resolved at request time.
+		return nil
+	}
+	return operators[op.Base].validate(v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,279 @@
+package condition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/condition"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func TestParseOperator(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		qualifier condition.Qualifier
+		ifExists  bool
+		wantErr   bool
+	}{
+		{"StringEquals", "StringEquals", condition.QualifierNone, false, false},
+		{"StringLikeIfExists", "StringLike", condition.QualifierNone, true, false},
+		{"ForAnyValue:StringEquals", "StringEquals", condition.QualifierForAnyValue, false, false},
+		{"ForAllValues:ArnLikeIfExists", "ArnLike", condition.QualifierForAllValues, true, false},
+		{"Null", "Null", condition.QualifierNone, false, false},
+		{"NullIfExists", "", condition.QualifierNone, false, true},
+		{"ForSomeValues:StringEquals", "", condition.QualifierNone, false, true},
+		{"StringEqualz", "", condition.QualifierNone, false, true},
+	}
+
+	for _, tt := range tests {
+		op, err := condition.ParseOperator(tt.name)
+		if tt.wantErr {
+			if !errors.Is(err, condition.ErrUnknownOperator) {
+				t.Errorf("ParseOperator(%q): expected ErrUnknownOperator, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseOperator(%q): unexpected error %v", tt.name, err)
+			continue
+		}
+		if op.Base != tt.base || op.Qualifier != tt.qualifier || op.IfExists != tt.ifExists {
+			t.Errorf("ParseOperator(%q) = %+v", tt.name, op)
+		}
+	}
+}
+
+func TestEvaluate_Operators(t *testing.T) {
+	tests := []struct {
+		op   string
+		want []string
+		ctx  []string
+		ok   bool
+	}{
+		{"StringEquals", []string{"prod"}, []string{"prod"}, true},
+		{"StringEquals", []string{"prod"}, []string{"Prod"}, false},
+		{"StringNotEquals", []string{"prod"}, []string{"dev"}, true},
+		{"StringEqualsIgnoreCase", []string{"prod"}, []string{"PROD"}, true},
+		{"StringNotEqualsIgnoreCase", []string{"prod"}, []string{"PROD"}, false},
+		{"StringLike", []string{"home/*"}, []string{"home/alice"}, true},
+		{"StringLike", []string{"home/*"}, []string{"HOME/alice"}, false},
+		{"StringNotLike", []string{"home/*"}, []string{"tmp/x"}, true},
+
+		{"NumericEquals", []string{"10"}, []string{"10.0"}, true},
+		{"NumericNotEquals", []string{"10"}, []string{"11"}, true},
+		{"NumericLessThan", []string{"10"}, []string{"9"}, true},
+		{"NumericLessThan", []string{"10"}, []string{"10"}, false},
+		{"NumericLessThanEquals", []string{"10"}, []string{"10"}, true},
+		{"NumericGreaterThan", []string{"10"}, []string{"11"}, true},
+		{"NumericGreaterThanEquals", []string{"10"}, []string{"9"}, false},
+
+		{"DateLessThan", []string{"2024-01-01T00:00:00Z"}, []string{"2023-06-01T12:00:00Z"}, true},
+		{"DateGreaterThan", []string{"2024-01-01"}, []string{"2024-06-01T00:00:00Z"}, true},
+		{"DateEquals", []string{"1704067200"}, []string{"2024-01-01T00:00:00Z"}, true},
+		{"DateNotEquals", []string{"2024-01-01T00:00:00Z"}, []string{"2024-01-01T00:00:00Z"}, false},
+		{"DateLessThanEquals", []string{"2024-01-01T00:00:00Z"}, []string{"2024-01-01T00:00:00Z"}, true},
+		{"DateGreaterThanEquals", []string{"2024-01-01T00:00:00Z"}, []string{"2023-01-01T00:00:00Z"}, false},
+
+		{"Bool", []string{"true"}, []string{"true"}, true},
+		{"Bool", []string{"false"}, []string{"true"}, false},
+		{"BinaryEquals", []string{"QmluYXJ5"}, []string{"QmluYXJ5"}, true},
+
+		{"IpAddress", []string{"203.0.113.0/24"}, []string{"203.0.113.7"}, true},
+		{"IpAddress", []string{"203.0.113.0/24"}, []string{"198.51.100.1"}, false},
+		{"IpAddress", []string{"2001:db8::/32"}, []string{"2001:db8::1"}, true},
+		{"NotIpAddress", []string{"203.0.113.0/24"}, []string{"198.51.100.1"}, true},
+
+		{"ArnEquals", []string{"arn:aws:sns:us-east-1:111122223333:topic"}, []string{"arn:aws:sns:us-east-1:111122223333:topic"}, true},
+		{"ArnLike", []string{"arn:aws:iam::*:role/ci-*"}, []string{"arn:aws:iam::111122223333:role/ci-deploy"}, true},
+		{"ArnLike", []string{"arn:aws:iam::*:role/ci-*"}, []string{"arn:aws:iam::111122223333:user/ci-deploy"}, false},
+		{"ArnNotLike", []string{"arn:aws:iam::*:role/ci-*"}, []string{"arn:aws:iam::111122223333:user/bob"}, true},
+
+		// Values of a single key are ORed.
+		{"StringEquals", []string{"dev", "prod"}, []string{"prod"}, true},
+	}
+
+	for _, tt := range tests {
+		c := model.Condition{tt.op: {"test:key": model.NewConditionValue(anys(tt.want)...)}}
+		ctx := condition.Context{"test:key": tt.ctx}
+
+		got, err := condition.Evaluate(c, ctx)
+		if err != nil {
+			t.Errorf("%s %v vs %v: unexpected error %v", tt.op, tt.want, tt.ctx, err)
+			continue
+		}
+		if got != tt.ok {
+			t.Errorf("%s %v vs %v = %v, want %v", tt.op, tt.want, tt.ctx, got, tt.ok)
+		}
+	}
+}
+
+func TestEvaluate_MissingKey(t *testing.T) {
+	tests := []struct {
+		op string
+		ok bool
+	}{
+		{"StringEquals", false},
+		{"StringEqualsIfExists", true},
+		{"StringNotEquals", true},
+		{"ForAnyValue:StringEquals", false},
+		{"ForAnyValue:StringEqualsIfExists", true},
+		{"ForAllValues:StringEquals", true},
+	}
+
+	for _, tt := range tests {
+		c := model.Condition{tt.op: {"aws:PrincipalTag/team": model.NewConditionValue("data")}}
+		got, err := condition.Evaluate(c, condition.Context{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.op, err)
+		}
+		if got != tt.ok {
+			t.Errorf("%s with missing key = %v, want %v", tt.op, got, tt.ok)
+		}
+	}
+}
+
+func TestEvaluate_Null(t *testing.T) {
+	present := condition.Context{"aws:multifactorauthage": {"300"}}
+
+	if ok, _ := condition.Evaluate(model.Condition{"Null": {"aws:MultiFactorAuthAge": model.NewConditionValue("true")}}, present); ok {
+		t.Error("Null=true should fail when the key is present")
+	}
+	if ok, _ := condition.Evaluate(model.Condition{"Null": {"aws:MultiFactorAuthAge": model.NewConditionValue("false")}}, present); !ok {
+		t.Error("Null=false should pass when the key is present")
+	}
+	if ok, _ := condition.Evaluate(model.Condition{"Null": {"aws:MultiFactorAuthAge": model.NewConditionValue("true")}}, condition.Context{}); !ok {
+		t.Error("Null=true should pass when the key is absent")
+	}
+}
+
+func TestEvaluate_SetQualifiers(t *testing.T) {
+	ctx := condition.Context{"aws:tagkeys": {"team", "env"}}
+
+	tests := []struct {
+		op   string
+		want []string
+		ok   bool
+	}{
+		{"ForAllValues:StringEquals", []string{"team", "env", "owner"}, true},
+		{"ForAllValues:StringEquals", []string{"team"}, false},
+		{"ForAnyValue:StringEquals", []string{"env"}, true},
+		{"ForAnyValue:StringEquals", []string{"owner"}, false},
+		{"ForAllValues:StringNotEquals", []string{"secret"}, true},
+		{"ForAnyValue:StringNotEquals", []string{"team"}, true},
+		{"ForAllValues:StringNotEquals", []string{"team"}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := condition.Evaluate(model.Condition{tt.op: {"aws:TagKeys": model.NewConditionValue(anys(tt.want)...)}}, ctx)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.op, err)
+		}
+		if got != tt.ok {
+			t.Errorf("%s %v = %v, want %v", tt.op, tt.want, got, tt.ok)
+		}
+	}
+}
+
+func TestEvaluate_BlocksAreANDed(t *testing.T) {
+	c := model.Condition{
+		"StringEquals": {"aws:PrincipalTag/team": model.NewConditionValue("data")},
+		"Bool":         {"aws:SecureTransport": model.NewConditionValue("true")},
+	}
+
+	ok, _ := condition.Evaluate(c, condition.Context{"aws:principaltag/team": {"data"}, "aws:securetransport": {"true"}})
+	if !ok {
+		t.Error("expected both blocks to pass")
+	}
+
+	ok, _ = condition.Evaluate(c, condition.Context{"aws:principaltag/team": {"data"}, "aws:securetransport": {"false"}})
+	if ok {
+		t.Error("expected failure when one block fails")
+	}
+}
+
+func TestEvaluate_PolicyVariables(t *testing.T) {
+	c := model.Condition{"StringLike": {"s3:prefix": model.NewConditionValue("home/${aws:username}/*")}}
+
+	ok, err := condition.Evaluate(c, condition.Context{"aws:username": {"alice"}, "s3:prefix": {"home/alice/docs"}})
+	if err != nil || !ok {
+		t.Errorf("expected variable substitution to match, got %v, %v", ok, err)
+	}
+
+	ok, _ = condition.Evaluate(c, condition.Context{"aws:username": {"bob"}, "s3:prefix": {"home/alice/docs"}})
+	if ok {
+		t.Error("expected mismatch for a different user")
+	}
+}
+
+func TestContext_Substitute(t *testing.T) {
+	ctx := condition.NewContext(map[string]model.ConditionValue{"aws:username": model.NewConditionValue("alice")})
+
+	tests := []struct{ in, want string }{
+		{"arn:aws:s3:::bucket/${aws:username}/*", "arn:aws:s3:::bucket/alice/*"},
+		{"${aws:PrincipalTag/team, 'none'}", "none"},
+		{"literal-${*}", "literal-*"},
+		{"${aws:unknown}", "${aws:unknown}"},
+	}
+
+	for _, tt := range tests {
+		if got := ctx.Substitute(tt.in); got != tt.want {
+			t.Errorf("Substitute(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContext_SubstitutePattern(t *testing.T) {
+	ctx := condition.NewContext(map[string]model.ConditionValue{"aws:username": model.NewConditionValue("a*b")})
+
+	tests := []struct{ in, want string }{
+		{"home/${aws:username}/*", `home/a\*b/*`},
+		{"literal-${*}-${?}-${$}", `literal-\*-\?-$`},
+		{`back\slash*`, `back\\slash*`},
+	}
+
+	for _, tt := range tests {
+		if got := ctx.SubstitutePattern(tt.in); got != tt.want {
+			t.Errorf("SubstitutePattern(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	c := model.Condition{"StringLike": {"s3:prefix": model.NewConditionValue("home/${*}")}}
+	if ok, _ := condition.Evaluate(c, condition.Context{"s3:prefix": {"home/alice"}}); ok {
+		t.Error("expected ${*} to match only a literal asterisk")
+	}
+	if ok, _ := condition.Evaluate(c, condition.Context{"s3:prefix": {"home/*"}}); !ok {
+		t.Error("expected ${*} to match a literal asterisk")
+	}
+}
+
+func TestEvaluate_InvalidPolicyValue(t *testing.T) {
+	c := model.Condition{"NumericLessThan": {"s3:max-keys": model.NewConditionValue("ten")}}
+	_, err := condition.Evaluate(c, condition.Context{"s3:max-keys": {"5"}})
+	if !errors.Is(err, condition.ErrInvalidValue) {
+		t.Errorf("expected ErrInvalidValue, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := model.Condition{
+		"NumericLessThan": {"s3:max-keys": model.NewConditionValue("ten")},
+		"IpAddress":       {"aws:SourceIp": model.NewConditionValue("10.0.0.0/8", "not-an-ip")},
+		"StringFoo":       {"aws:username": model.NewConditionValue("alice")},
+		"Bool":            {"aws:SecureTransport": model.NewConditionValue("true")},
+		"DateGreaterThan": {"aws:CurrentTime": model.NewConditionValue("${aws:EpochTime}")},
+	}
+
+	errs := condition.Validate(c)
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func anys(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package condition
+
+import (
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Context holds the request context keys. Keys are stored lower-cased because
+// IAM condition keys are case-insensitive; values keep their case.
+type Context map[string][]string
+
+func NewContext(raw map[string]model.ConditionValue) Context {
+	ctx := make(Context, len(raw))
+	for k, v := range raw {
+		ctx[strings.ToLower(k)] = v.Strings()
+	}
+	return ctx
+}
+
+func (c Context) Lookup(key string) ([]string, bool) {
+	v, ok := c[strings.ToLower(key)]
+	return v, ok
+}
+
+// Substitute resolves ${key} policy variables against the context. Unknown
+// single-valued keys fall back to an inline default (${key, 'default'}) or are
+// left untouched, which makes the surrounding value unlikely to match.
+func (c Context) Substitute(s string) string {
+	return c.substitute(s, identity, identity)
+}
+
+// SubstitutePattern is Substitute for a wildcard pattern, to be matched with
+// graph.MatchEscaped. Substituted values match only themselves, so ${*} is a
+// literal asterisk, while the wildcards written in s keep their meaning.
+func (c Context) SubstitutePattern(s string) string {
+	return c.substitute(s, escapeBackslashes, graph.QuoteMeta)
+}
+
+// substitute passes the text around variables through text and each
+// resolved variable through value.
+func (c Context) substitute(s string, text, value func(string) string) string {
+	if !strings.Contains(s, "${") {
+		return text(s)
+	}
+
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			sb.WriteString(text(s))
+			return sb.String()
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			sb.WriteString(text(s))
+			return sb.String()
+		}
+		end += start
+
+		sb.WriteString(text(s[:start]))
+		sb.WriteString(value(c.resolve(s[start : end+1])))
+		s = s[end+1:]
+	}
+}
+
+func identity(s string) string { return s }
+
+func escapeBackslashes(s string) string {
+	return strings.ReplaceAll(s, `\`, `\\`)
+}
+
+func (c Context) resolve(variable string) string {
+	inner := strings.TrimSpace(variable[2 : len(variable)-1])
+
+	switch inner {
+	case "*", "?", "$":
+		return inner
+	}
+
+	key, def, hasDefault := strings.Cut(inner, ",")
+	key = strings.TrimSpace(key)
+	if v, ok := c.Lookup(key); ok && len(v) == 1 {
+		return v[0]
+	}
+	if hasDefault {
+		return strings.Trim(strings.TrimSpace(def), "'")
+	}
+	return variable
+}
+
+// substituteAll substitutes each of values, as patterns when pattern is set.
+func (c Context) substituteAll(values []string, pattern bool) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if pattern {
+			out[i] = c.SubstitutePattern(v)
+		} else {
+			out[i] = c.Substitute(v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,249 @@
+package condition
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+)
+
+// comparator reports whether a single context value satisfies a single policy value.
+type comparator func(want, got string) (bool, error)
+
+type operatorSpec struct {
+	compare  comparator
+	validate func(want string) error
+	negated  bool
+
+	// pattern is set for operators whose policy values are wildcard
+	// patterns; policy variables are substituted into them literally.
+	pattern bool
+}
+
+var operators = map[string]operatorSpec{
+	"StringEquals":              {compare: stringEquals, validate: anyString},
+	"StringNotEquals":           {compare: stringEquals, validate: anyString, negated: true},
+	"StringEqualsIgnoreCase":    {compare: stringEqualsIgnoreCase, validate: anyString},
+	"StringNotEqualsIgnoreCase": {compare: stringEqualsIgnoreCase, validate: anyString, negated: true},
+	"StringLike":                {compare: stringLike, validate: anyString, pattern: true},
+	"StringNotLike":             {compare: stringLike, validate: anyString, negated: true, pattern: true},
+
+	"NumericEquals":            {compare: numeric(func(c int) bool { return c == 0 }), validate: validNumber},
+	"NumericNotEquals":         {compare: numeric(func(c int) bool { return c == 0 }), validate: validNumber, negated: true},
+	"NumericLessThan":          {compare: numeric(func(c int) bool { return c < 0 }), validate: validNumber},
+	"NumericLessThanEquals":    {compare: numeric(func(c int) bool { return c <= 0 }), validate: validNumber},
+	"NumericGreaterThan":       {compare: numeric(func(c int) bool { return c > 0 }), validate: validNumber},
+	"NumericGreaterThanEquals": {compare: numeric(func(c int) bool { return c >= 0 }), validate: validNumber},
+
+	"DateEquals":            {compare: date(func(c int) bool { return c == 0 }), validate: validDate},
+	"DateNotEquals":         {compare: date(func(c int) bool { return c == 0 }), validate: validDate, negated: true},
+	"DateLessThan":          {compare: date(func(c int) bool { return c < 0 }), validate: validDate},
+	"DateLessThanEquals":    {compare: date(func(c int) bool { return c <= 0 }), validate: validDate},
+	"DateGreaterThan":       {compare: date(func(c int) bool { return c > 0 }), validate: validDate},
+	"DateGreaterThanEquals": {compare: date(func(c int) bool { return c >= 0 }), validate: validDate},
+
+	"Bool":         {compare: boolEquals, validate: validBool},
+	"BinaryEquals": {compare: binaryEquals, validate: validBinary},
+
+	"IpAddress":    {compare: ipAddress, validate: validIP},
+	"NotIpAddress": {compare: ipAddress, validate: validIP, negated: true},
+
+	"ArnEquals":    {compare: arnLike, validate: validArn, pattern: true},
+	"ArnLike":      {compare: arnLike, validate: validArn, pattern: true},
+	"ArnNotEquals": {compare: arnLike, validate: validArn, negated: true, pattern: true},
+	"ArnNotLike":   {compare: arnLike, validate: validArn, negated: true, pattern: true},
+
+	"Null": {},
+}
+
+func anyString(string) error { return nil }
+
+func stringEquals(want, got string) (bool, error) {
+	return want == got, nil
+}
+
+func stringEqualsIgnoreCase(want, got string) (bool, error) {
+	return strings.EqualFold(want, got), nil
+}
+
+// stringLike takes want as written by Context.SubstitutePattern.
+func stringLike(want, got string) (bool, error) {
+	return graph.MatchEscapedCaseSensitive(want, got), nil
+}
+
+func numeric(accept func(cmp int) bool) comparator {
+	return func(want, got string) (bool, error) {
+		w, err := parseNumber(want)
+		if err != nil {
+			return false, err
+		}
+		g, err := parseNumber(got)
+		if err != nil {
+			// A context value that is not a number can never satisfy a numeric operator.
+			return false, nil
+		}
+		return accept(compareFloats(g, w)), nil
+	}
+}
+
+func parseNumber(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidValue, s)
+	}
+	return f, nil
+}
+
+func validNumber(s string) error {
+	_, err := parseNumber(s)
+	return err
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func date(accept func(cmp int) bool) comparator {
+	return func(want, got string) (bool, error) {
+		w, err := parseDate(want)
+		if err != nil {
+			return false, err
+		}
+		g, err := parseDate(got)
+		if err != nil {
+			return false, nil
+		}
+		return accept(g.Compare(w)), nil
+	}
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// parseDate accepts the ISO 8601 forms IAM documents as well as epoch seconds.
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if epoch, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q is not a date", ErrInvalidValue, s)
+}
+
+func validDate(s string) error {
+	_, err := parseDate(s)
+	return err
+}
+
+func boolEquals(want, got string) (bool, error) {
+	if err := validBool(want); err != nil {
+		return false, err
+	}
+	return strings.EqualFold(want, got), nil
+}
+
+func validBool(s string) error {
+	if !strings.EqualFold(s, "true") && !strings.EqualFold(s, "false") {
+		return fmt.Errorf("%w: %q is not a boolean", ErrInvalidValue, s)
+	}
+	return nil
+}
+
+func binaryEquals(want, got string) (bool, error) {
+	w, err := base64.StdEncoding.DecodeString(want)
+	if err != nil {
+		return false, fmt.Errorf("%w: %q is not base64", ErrInvalidValue, want)
+	}
+	g, err := base64.StdEncoding.DecodeString(got)
+	if err != nil {
+		return false, nil
+	}
+	return bytes.Equal(w, g), nil
+}
+
+func validBinary(s string) error {
+	_, err := binaryEquals(s, s)
+	return err
+}
+
+func ipAddress(want, got string) (bool, error) {
+	prefix, err := parsePrefix(want)
+	if err != nil {
+		return false, err
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(got))
+	if err != nil {
+		return false, nil
+	}
+	return prefix.Contains(addr.Unmap()), nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%w: %q is not a CIDR block", ErrInvalidValue, s)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %q is not an IP address", ErrInvalidValue, s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func validIP(s string) error {
+	_, err := parsePrefix(s)
+	return err
+}
+
+// arnLike compares each of the six colon-separated ARN components on its own,
+// so a wildcard in one component cannot swallow a separator.
+func arnLike(want, got string) (bool, error) {
+	if want == "*" {
+		return true, nil
+	}
+	w := strings.SplitN(want, ":", 6)
+	if len(w) != 6 || w[0] != "arn" {
+		return false, fmt.Errorf("%w: %q is not an ARN", ErrInvalidValue, want)
+	}
+	g := strings.SplitN(got, ":", 6)
+	if len(g) != 6 {
+		return false, nil
+	}
+	for i := range w {
+		if !graph.MatchEscapedCaseSensitive(w[i], g[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func validArn(s string) error {
+	_, err := arnLike(s, s)
+	return err
+}
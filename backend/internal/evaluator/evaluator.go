@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"github.com/Kuba0517/iam-analyzer/internal/condition"
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func Evaluate(p *model.Policy, req model.AccessRequest) model.SimulateResponse {
+	ctx := condition.NewContext(req.Context)
+	// Policy variables were introduced with the 2012-10-17 language version.
+	variables := p.Version == "2012-10-17"
+
+	var allows, denies []int
+	for i, s := range p.Statement {
+		if !statementApplies(s, req, ctx, variables) {
+			continue
+		}
+		switch s.Effect {
+		case "Allow":
+			allows = append(allows, i)
+		case "Deny":
+			denies = append(denies, i)
+		}
+	}
+
+	// An explicit Deny always wins, regardless of how many statements allow the request.
+	if len(denies) > 0 {
+		return model.SimulateResponse{Decision: model.DecisionExplicitDeny, MatchedStatements: denies}
+	}
+	if len(allows) > 0 {
+		return model.SimulateResponse{Decision: model.DecisionAllow, MatchedStatements: allows}
+	}
+	return model.SimulateResponse{Decision: model.DecisionImplicitDeny, MatchedStatements: []int{}}
+}
+
+func statementApplies(s model.Statement, req model.AccessRequest, ctx condition.Context, variables bool) bool {
+	if len(s.Action) > 0 && !matchesAny(s.Action, req.Action, nil) {
+		return false
+	}
+	if len(s.NotAction) > 0 && matchesAny(s.NotAction, req.Action, nil) {
+		return false
+	}
+
+	resourceCtx := ctx
+	if !variables {
+		resourceCtx = nil
+	}
+	if len(s.Resource) > 0 && !matchesAny(s.Resource, req.Resource, resourceCtx) {
+		return false
+	}
+	if len(s.NotResource) > 0 && matchesAny(s.NotResource, req.Resource, resourceCtx) {
+		return false
+	}
+
+	if s.Principal != nil && !principalMatches(s.Principal, req.Principal) {
+		return false
+	}
+	if s.NotPrincipal != nil && principalMatches(s.NotPrincipal, req.Principal) {
+		return false
+	}
+
+	// A condition that cannot be evaluated never applies, so a malformed
+	// condition cannot widen what an Allow grants.
+	ok, err := condition.Evaluate(s.Condition, ctx)
+	return ok && err == nil
+}
+
+func matchesAny(patterns []string, value string, ctx condition.Context) bool {
+	for _, p := range patterns {
+		if ctx != nil {
+			p = ctx.SubstitutePattern(p)
+		}
+		if graph.MatchEscaped(p, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package evaluator_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/evaluator"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func TestEvaluate_Allow(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:Get*"}, Resource: model.StringOrSlice{"arn:aws:s3:::logs/*"}},
+		},
+	}
+
+	res := evaluator.Evaluate(p, model.AccessRequest{Action: "s3:GetObject", Resource: "arn:aws:s3:::logs/2024/a.gz"})
+	if res.Decision != model.DecisionAllow {
+		t.Fatalf("expected Allow, got %s", res.Decision)
+	}
+	if !reflect.DeepEqual(res.MatchedStatements, []int{0}) {
+		t.Errorf("expected matched statements [0], got %v", res.MatchedStatements)
+	}
+}
+
+func TestEvaluate_LiteralVariables(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::bucket/${*}"}},
+		},
+	}
+
+	for _, tt := range []struct {
+		resource string
+		want     model.Decision
+	}{
+		{"arn:aws:s3:::bucket/*", model.DecisionAllow},
+		{"arn:aws:s3:::bucket/secret.txt", model.DecisionImplicitDeny},
+	} {
+		res := evaluator.Evaluate(p, model.AccessRequest{Action: "s3:GetObject", Resource: tt.resource})
+		if res.Decision != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.resource, tt.want, res.Decision)
+		}
+	}
+}
+
+func TestEvaluate_ImplicitDeny(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::logs/*"}},
+		},
+	}
+
+	res := evaluator.Evaluate(p, model.AccessRequest{Action: "s3:PutObject", Resource: "arn:aws:s3:::logs/a.gz"})
+	if res.Decision != model.DecisionImplicitDeny {
+		t.Fatalf("expected ImplicitDeny, got %s", res.Decision)
+	}
+	if len(res.MatchedStatements) != 0 {
+		t.Errorf("expected no matched statements, got %v", res.MatchedStatements)
+	}
+}
+
+func TestEvaluate_ExplicitDenyWins(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::secret/*"}},
+		},
+	}
+
+	res := evaluator.Evaluate(p, model.AccessRequest{Action: "s3:GetObject", Resource: "arn:aws:s3:::secret/key"})
+	if res.Decision != model.DecisionExplicitDeny {
+		t.Fatalf("expected ExplicitDeny, got %s", res.Decision)
+	}
+	if !reflect.DeepEqual(res.MatchedStatements, []int{1}) {
+		t.Errorf("expected matched statements [1], got %v", res.MatchedStatements)
+	}
+}
+
+func TestEvaluate_NotActionAndNotResource(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", NotAction: model.StringOrSlice{"iam:*"}, NotResource: model.StringOrSlice{"arn:aws:s3:::secret/*"}},
+		},
+	}
+
+	tests := []struct {
+		action, resource string
+		want             model.Decision
+	}{
+		{"s3:GetObject", "arn:aws:s3:::public/a", model.DecisionAllow},
+		{"iam:CreateUser", "arn:aws:s3:::public/a", model.DecisionImplicitDeny},
+		{"s3:GetObject", "arn:aws:s3:::secret/a", model.DecisionImplicitDeny},
+	}
+
+	for _, tt := range tests {
+		res := evaluator.Evaluate(p, model.AccessRequest{Action: tt.action, Resource: tt.resource})
+		if res.Decision != tt.want {
+			t.Errorf("%s on %s: expected %s, got %s", tt.action, tt.resource, tt.want, res.Decision)
+		}
+	}
+}
+
+func TestEvaluate_Principal(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:    "Allow",
+				Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:root"}}},
+				Action:    model.StringOrSlice{"s3:GetObject"},
+				Resource:  model.StringOrSlice{"arn:aws:s3:::bucket/*"},
+			},
+		},
+	}
+
+	tests := []struct {
+		principal *model.RequestPrincipal
+		want      model.Decision
+	}{
+		{&model.RequestPrincipal{Type: "AWS", ID: "arn:aws:iam::111122223333:role/reader"}, model.DecisionAllow},
+		{&model.RequestPrincipal{Type: "AWS", ID: "arn:aws:iam::444455556666:role/reader"}, model.DecisionImplicitDeny},
+		{&model.RequestPrincipal{Type: "Service", ID: "lambda.amazonaws.com"}, model.DecisionImplicitDeny},
+		{nil, model.DecisionImplicitDeny},
+	}
+
+	for _, tt := range tests {
+		res := evaluator.Evaluate(p, model.AccessRequest{Principal: tt.principal, Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/key"})
+		if res.Decision != tt.want {
+			t.Errorf("principal %+v: expected %s, got %s", tt.principal, tt.want, res.Decision)
+		}
+	}
+}
+
+func TestEvaluate_NotPrincipal(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:       "Deny",
+				NotPrincipal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:role/admin"}}},
+				Action:       model.StringOrSlice{"s3:*"},
+				Resource:     model.StringOrSlice{"*"},
+			},
+			{Effect: "Allow", Principal: &model.Principal{Wildcard: true}, Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	admin := &model.RequestPrincipal{Type: "AWS", ID: "arn:aws:iam::111122223333:role/admin"}
+	other := &model.RequestPrincipal{Type: "AWS", ID: "arn:aws:iam::111122223333:role/dev"}
+
+	if res := evaluator.Evaluate(p, model.AccessRequest{Principal: admin, Action: "s3:GetObject", Resource: "arn:aws:s3:::b/k"}); res.Decision != model.DecisionAllow {
+		t.Errorf("expected Allow for admin, got %s", res.Decision)
+	}
+	if res := evaluator.Evaluate(p, model.AccessRequest{Principal: other, Action: "s3:GetObject", Resource: "arn:aws:s3:::b/k"}); res.Decision != model.DecisionExplicitDeny {
+		t.Errorf("expected ExplicitDeny for other principal, got %s", res.Decision)
+	}
+}
+
+func TestEvaluate_Condition(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+			{
+				Effect:    "Deny",
+				Action:    model.StringOrSlice{"s3:*"},
+				Resource:  model.StringOrSlice{"*"},
+				Condition: model.Condition{"Bool": {"aws:SecureTransport": model.NewConditionValue(false)}},
+			},
+		},
+	}
+
+	insecure := model.AccessRequest{
+		Action:   "s3:GetObject",
+		Resource: "arn:aws:s3:::b/k",
+		Context:  map[string]model.ConditionValue{"aws:securetransport": model.NewConditionValue(false)},
+	}
+	if res := evaluator.Evaluate(p, insecure); res.Decision != model.DecisionExplicitDeny {
+		t.Errorf("expected ExplicitDeny over insecure transport, got %s", res.Decision)
+	}
+
+	secure := insecure
+	secure.Context = map[string]model.ConditionValue{"aws:SecureTransport": model.NewConditionValue(true)}
+	if res := evaluator.Evaluate(p, secure); res.Decision != model.DecisionAllow {
+		t.Errorf("expected Allow over secure transport, got %s", res.Decision)
+	}
+}
@@ -0,0 +1,39 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func principalMatches(p *model.Principal, rp *model.RequestPrincipal) bool {
+	if p.Wildcard {
+		return true
+	}
+	if rp == nil {
+		return false
+	}
+
+	for typ, ids := range p.Members {
+		if !strings.EqualFold(typ, rp.Type) {
+			continue
+		}
+		for _, id := range ids {
+			if graph.Match(id, rp.ID) {
+				return true
+			}
+			if acct := arn.Account(id); acct != "" && isAccountPrincipal(id) && acct == arn.Account(rp.ID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// An account ID or its root ARN names the whole account, so it matches every
+// principal that lives in it.
+func isAccountPrincipal(id string) bool {
+	return arn.IsAccountID(id) || (strings.HasPrefix(id, "arn:") && strings.HasSuffix(id, ":root"))
+}
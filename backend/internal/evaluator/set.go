@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// EvaluateSet combines several policies following the AWS policy evaluation
+// logic: an explicit Deny anywhere wins, SCPs must allow, a resource-based
+// policy can grant access on its own within an account, and otherwise the
+// identity-based policies must allow and be confirmed by every permission
+// boundary and session policy that is present.
+//
+// All SCPs in the set are treated as one level of the organization, so an
+// Allow in any of them is enough.
+func EvaluateSet(set model.PolicySet, req model.AccessRequest) model.EvaluateResponse {
+	results := make([]model.PolicyResult, 0, len(set.Policies))
+	for i, tp := range set.Policies {
+		res := Evaluate(tp.Policy, req)
+		results = append(results, model.PolicyResult{
+			Index:             i,
+			Type:              tp.Type,
+			Name:              tp.Name,
+			Decision:          res.Decision,
+			MatchedStatements: res.MatchedStatements,
+		})
+	}
+
+	resp := model.EvaluateResponse{Policies: results}
+
+	for _, r := range results {
+		if r.Decision == model.DecisionExplicitDeny {
+			return deny(resp, model.DecisionExplicitDeny, r.Type, fmt.Sprintf("explicitly denied by %s", describe(r)))
+		}
+	}
+
+	if present(results, model.PolicyTypeSCP) && !allowedBy(results, model.PolicyTypeSCP) {
+		return deny(resp, model.DecisionImplicitDeny, model.PolicyTypeSCP, "no service control policy allows the action")
+	}
+
+	identityAllows := allowedBy(results, model.PolicyTypeIdentity)
+	resourceAllows := allowedBy(results, model.PolicyTypeResource)
+
+	if crossAccount(req) {
+		if !resourceAllows {
+			return deny(resp, model.DecisionImplicitDeny, model.PolicyTypeResource, "cross-account access requires the resource-based policy to allow the request")
+		}
+	} else if resourceAllows {
+		// Within one account a resource-based policy grant is not limited by
+		// permission boundaries or session policies.
+		resp.Decision = model.DecisionAllow
+		resp.Reason = "allowed by resource-based policy"
+		return resp
+	}
+
+	if !identityAllows {
+		return deny(resp, model.DecisionImplicitDeny, model.PolicyTypeIdentity, "no identity-based policy allows the action")
+	}
+	if present(results, model.PolicyTypePermissionBoundary) && !allowedBy(results, model.PolicyTypePermissionBoundary) {
+		return deny(resp, model.DecisionImplicitDeny, model.PolicyTypePermissionBoundary, "the permission boundary does not allow the action")
+	}
+	if present(results, model.PolicyTypeSession) && !allowedBy(results, model.PolicyTypeSession) {
+		return deny(resp, model.DecisionImplicitDeny, model.PolicyTypeSession, "the session policy does not allow the action")
+	}
+
+	resp.Decision = model.DecisionAllow
+	resp.Reason = "allowed by identity-based policy"
+	if crossAccount(req) {
+		resp.Reason = "allowed by identity-based and resource-based policies"
+	}
+	return resp
+}
+
+func deny(resp model.EvaluateResponse, d model.Decision, layer model.PolicyType, reason string) model.EvaluateResponse {
+	resp.Decision = d
+	resp.DeniedBy = layer
+	resp.Reason = reason
+	return resp
+}
+
+func present(results []model.PolicyResult, t model.PolicyType) bool {
+	for _, r := range results {
+		if r.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedBy(results []model.PolicyResult, t model.PolicyType) bool {
+	for _, r := range results {
+		if r.Type == t && r.Decision == model.DecisionAllow {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(r model.PolicyResult) string {
+	if r.Name != "" {
+		return fmt.Sprintf("%s policy %q", r.Type, r.Name)
+	}
+	return fmt.Sprintf("%s policy %d", r.Type, r.Index)
+}
+
+// crossAccount is only true when both accounts are known; S3 ARNs, for
+// example, carry no account so the caller has to set ResourceAccount.
+func crossAccount(req model.AccessRequest) bool {
+	if req.Principal == nil {
+		return false
+	}
+	principalAcct := arn.Account(req.Principal.ID)
+
+	resourceAcct := req.ResourceAccount
+	if resourceAcct == "" {
+		resourceAcct = arn.Account(req.Resource)
+	}
+
+	return principalAcct != "" && resourceAcct != "" && principalAcct != resourceAcct
+}
+
+func ValidPolicyType(t model.PolicyType) bool {
+	switch t {
+	case model.PolicyTypeIdentity, model.PolicyTypeResource, model.PolicyTypeSCP,
+		model.PolicyTypePermissionBoundary, model.PolicyTypeSession:
+		return true
+	default:
+		return false
+	}
+}
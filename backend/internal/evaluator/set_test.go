@@ -0,0 +1,163 @@
+package evaluator_test
+
+import (
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/evaluator"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+func allowPolicy(actions ...string) *model.Policy {
+	return &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: actions, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+}
+
+func denyPolicy(actions ...string) *model.Policy {
+	return &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Deny", Action: actions, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+}
+
+var role = &model.RequestPrincipal{Type: "AWS", ID: "arn:aws:iam::111122223333:role/app"}
+
+func TestEvaluateSet(t *testing.T) {
+	req := model.AccessRequest{Principal: role, Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/key"}
+
+	tests := []struct {
+		name     string
+		policies []model.TypedPolicy
+		want     model.Decision
+		deniedBy model.PolicyType
+	}{
+		{
+			name:     "identity allows",
+			policies: []model.TypedPolicy{{Type: model.PolicyTypeIdentity, Policy: allowPolicy("s3:*")}},
+			want:     model.DecisionAllow,
+		},
+		{
+			name:     "nothing allows",
+			policies: []model.TypedPolicy{{Type: model.PolicyTypeIdentity, Policy: allowPolicy("ec2:*")}},
+			want:     model.DecisionImplicitDeny,
+			deniedBy: model.PolicyTypeIdentity,
+		},
+		{
+			name: "scp denies explicitly",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeIdentity, Policy: allowPolicy("s3:*")},
+				{Type: model.PolicyTypeSCP, Policy: denyPolicy("s3:GetObject")},
+			},
+			want:     model.DecisionExplicitDeny,
+			deniedBy: model.PolicyTypeSCP,
+		},
+		{
+			name: "scp does not allow",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeIdentity, Policy: allowPolicy("s3:*")},
+				{Type: model.PolicyTypeSCP, Policy: allowPolicy("ec2:*")},
+			},
+			want:     model.DecisionImplicitDeny,
+			deniedBy: model.PolicyTypeSCP,
+		},
+		{
+			name: "permission boundary limits identity",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeIdentity, Policy: allowPolicy("s3:*")},
+				{Type: model.PolicyTypePermissionBoundary, Policy: allowPolicy("s3:PutObject")},
+			},
+			want:     model.DecisionImplicitDeny,
+			deniedBy: model.PolicyTypePermissionBoundary,
+		},
+		{
+			name: "session policy limits identity",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeIdentity, Policy: allowPolicy("s3:*")},
+				{Type: model.PolicyTypePermissionBoundary, Policy: allowPolicy("s3:*")},
+				{Type: model.PolicyTypeSession, Policy: allowPolicy("s3:List*")},
+			},
+			want:     model.DecisionImplicitDeny,
+			deniedBy: model.PolicyTypeSession,
+		},
+		{
+			name: "resource policy grants within the account",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeResource, Policy: &model.Policy{
+					Version: "2012-10-17",
+					Statement: []model.Statement{{
+						Effect:    "Allow",
+						Principal: &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111122223333:role/app"}}},
+						Action:    model.StringOrSlice{"s3:GetObject"},
+						Resource:  model.StringOrSlice{"arn:aws:s3:::bucket/*"},
+					}},
+				}},
+				{Type: model.PolicyTypePermissionBoundary, Policy: allowPolicy("ec2:*")},
+			},
+			want: model.DecisionAllow,
+		},
+		{
+			name: "identity deny beats resource allow",
+			policies: []model.TypedPolicy{
+				{Type: model.PolicyTypeIdentity, Policy: denyPolicy("s3:*")},
+				{Type: model.PolicyTypeResource, Policy: &model.Policy{
+					Version: "2012-10-17",
+					Statement: []model.Statement{{
+						Effect:    "Allow",
+						Principal: &model.Principal{Wildcard: true},
+						Action:    model.StringOrSlice{"s3:GetObject"},
+						Resource:  model.StringOrSlice{"*"},
+					}},
+				}},
+			},
+			want:     model.DecisionExplicitDeny,
+			deniedBy: model.PolicyTypeIdentity,
+		},
+	}
+
+	for _, tt := range tests {
+		res := evaluator.EvaluateSet(model.PolicySet{Policies: tt.policies}, req)
+		if res.Decision != tt.want {
+			t.Errorf("%s: expected %s, got %s (%s)", tt.name, tt.want, res.Decision, res.Reason)
+		}
+		if res.DeniedBy != tt.deniedBy {
+			t.Errorf("%s: expected deniedBy %q, got %q", tt.name, tt.deniedBy, res.DeniedBy)
+		}
+		if len(res.Policies) != len(tt.policies) {
+			t.Errorf("%s: expected %d policy results, got %d", tt.name, len(tt.policies), len(res.Policies))
+		}
+	}
+}
+
+func TestEvaluateSet_CrossAccount(t *testing.T) {
+	queue := "arn:aws:sqs:us-east-1:444455556666:orders"
+	resourcePolicy := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{{
+			Effect:    "Allow",
+			Principal: &model.Principal{Members: map[string][]string{"AWS": {"111122223333"}}},
+			Action:    model.StringOrSlice{"sqs:SendMessage"},
+			Resource:  model.StringOrSlice{queue},
+		}},
+	}
+	req := model.AccessRequest{Principal: role, Action: "sqs:SendMessage", Resource: queue}
+
+	onlyResource := model.PolicySet{Policies: []model.TypedPolicy{{Type: model.PolicyTypeResource, Policy: resourcePolicy}}}
+	if res := evaluator.EvaluateSet(onlyResource, req); res.Decision != model.DecisionImplicitDeny || res.DeniedBy != model.PolicyTypeIdentity {
+		t.Errorf("expected identity implicit deny, got %s by %q", res.Decision, res.DeniedBy)
+	}
+
+	onlyIdentity := model.PolicySet{Policies: []model.TypedPolicy{{Type: model.PolicyTypeIdentity, Policy: allowPolicy("sqs:*")}}}
+	if res := evaluator.EvaluateSet(onlyIdentity, req); res.Decision != model.DecisionImplicitDeny || res.DeniedBy != model.PolicyTypeResource {
+		t.Errorf("expected resource implicit deny, got %s by %q", res.Decision, res.DeniedBy)
+	}
+
+	both := model.PolicySet{Policies: append(onlyIdentity.Policies, onlyResource.Policies...)}
+	if res := evaluator.EvaluateSet(both, req); res.Decision != model.DecisionAllow {
+		t.Errorf("expected Allow, got %s (%s)", res.Decision, res.Reason)
+	}
+}
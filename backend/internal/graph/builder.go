@@ -11,6 +11,7 @@ import (
 
 func Build(p *model.Policy) *Graph {
 	g := New()
+	sv := newSolver()
 
 	for i, s := range p.Statement {
 		g.AddNode(Node{
@@ -21,16 +22,20 @@ func Build(p *model.Policy) *Graph {
 
 	for i := 0; i < len(p.Statement); i++ {
 		for j := i + 1; j < len(p.Statement); j++ {
-			addRelationshipEdges(g, p, i, j)
+			addRelationshipEdges(g, sv, p, i, j)
 		}
 	}
 
-	addDenyAllowEdges(g, p)
+	addDenyAllowEdges(g, sv, p)
+
+	for i := range g.nodes {
+		g.nodes[i].ShadowedBy = shadowingDenies(sv, p, i)
+	}
 
 	return g
 }
 
-func addRelationshipEdges(g *Graph, p *model.Policy, i, j int) {
+func addRelationshipEdges(g *Graph, sv *solver, p *model.Policy, i, j int) {
 	a := p.Statement[i]
 	b := p.Statement[j]
 
@@ -42,81 +47,71 @@ func addRelationshipEdges(g *Graph, p *model.Policy, i, j int) {
 	if a.Effect != b.Effect {
 		return
 	}
+
+	// A covered statement can simply be removed, so it is not also offered
+	// for merging.
+	if sv.subsumes(a, b) {
+		g.AddEdge(Edge{From: i, To: j, Type: Subsumes})
+		return
+	}
+	if sv.subsumes(b, a) {
+		g.AddEdge(Edge{From: j, To: i, Type: Subsumes})
+		return
+	}
 	if !reflect.DeepEqual(a.Condition, b.Condition) {
 		return
 	}
-	if !reflect.DeepEqual(a.Principal, b.Principal) {
+	if !reflect.DeepEqual(a.Principal, b.Principal) || !reflect.DeepEqual(a.NotPrincipal, b.NotPrincipal) {
 		return
 	}
 
-	if reflect.DeepEqual(a.Resource, b.Resource) && !reflect.DeepEqual(a.Action, b.Action) {
+	// Lists are only merged when both are positive: the union of two
+	// NotAction statements is everything except what both exclude, which
+	// one statement cannot express together with the other elements.
+	sameActions := actionSet(a).equal(actionSet(b))
+	sameResources := resourceSet(a).equal(resourceSet(b))
+
+	if sameResources && !sameActions && len(a.NotAction) == 0 && len(b.NotAction) == 0 {
 		g.AddEdge(Edge{From: i, To: j, Type: MergeableAction})
 	}
 
-	if reflect.DeepEqual(a.Action, b.Action) && !reflect.DeepEqual(a.Resource, b.Resource) {
+	if sameActions && !sameResources && len(a.NotResource) == 0 && len(b.NotResource) == 0 {
 		g.AddEdge(Edge{From: i, To: j, Type: MergeableResource})
 	}
 }
 
-func addDenyAllowEdges(g *Graph, p *model.Policy) {
-	type actionSource struct {
-		action string
-		index  int
-	}
-
-	var allows, denies []actionSource
-	for i, s := range p.Statement {
-		for _, a := range s.Action {
-			if s.Effect == "Allow" {
-				allows = append(allows, actionSource{action: a, index: i})
-			} else if s.Effect == "Deny" {
-				denies = append(denies, actionSource{action: a, index: i})
-			}
+// addDenyAllowEdges links each Allow to each Deny that applies to some of
+// the same requests: their actions, resources and principals all intersect.
+// The overlap is conditional when the Deny can fail to apply while the Allow
+// does, because of a condition the Allow does not also have.
+func addDenyAllowEdges(g *Graph, sv *solver, p *model.Policy) {
+	for d, deny := range p.Statement {
+		if deny.Effect != "Deny" {
+			continue
 		}
-	}
-
-	seen := make(map[[2]int]bool)
-	for _, d := range denies {
-		for _, a := range allows {
-			if !Overlaps(d.action, a.action) {
+		for a, allow := range p.Statement {
+			if allow.Effect != "Allow" {
 				continue
 			}
-			pair := [2]int{a.index, d.index}
-			if seen[pair] {
+
+			allowActions, denyActions := actionSet(allow), actionSet(deny)
+			allowResources, denyResources := resourceSet(allow), resourceSet(deny)
+			if !sv.overlap(allowActions, denyActions, actionNames) ||
+				!sv.overlap(allowResources, denyResources, anyString) ||
+				!sv.principalsOverlap(allow, deny) {
 				continue
 			}
-			seen[pair] = true
-
-			overlapping := collectOverlappingActions(p.Statement[a.index], p.Statement[d.index])
-			g.AddEdge(Edge{
-				From: a.index,
-				To:   d.index,
-				Type: DenyAllowOverlap,
-				Meta: EdgeMeta{OverlappingActions: overlapping},
-			})
-		}
-	}
-}
 
-func collectOverlappingActions(allow, deny model.Statement) []string {
-	var result []string
-	seen := make(map[string]bool)
-	for _, a := range allow.Action {
-		for _, d := range deny.Action {
-			if Overlaps(a, d) {
-				key := a + "|" + d
-				if !seen[key] {
-					seen[key] = true
-					if hasWildcard(a) && !hasWildcard(d) {
-						result = append(result, d)
-					} else {
-						result = append(result, a)
-					}
-				}
+			meta := EdgeMeta{
+				OverlappingActions: sv.commonPatterns(allowActions, denyActions, actionNames),
+				Conditional:        !conditionCovers(deny.Condition, allow.Condition),
+			}
+			if len(allow.Resource) > 0 || len(allow.NotResource) > 0 || len(deny.Resource) > 0 || len(deny.NotResource) > 0 {
+				meta.OverlappingResources = sv.commonPatterns(allowResources, denyResources, anyString)
 			}
+			g.AddEdge(Edge{From: a, To: d, Type: DenyAllowOverlap, Meta: meta})
 		}
 	}
-	return result
 }
 
 func fingerprint(s model.Statement) string {
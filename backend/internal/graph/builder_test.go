@@ -1,7 +1,9 @@
 package graph
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
@@ -104,6 +106,475 @@ func TestBuild_DenyAllowOverlap_Wildcard(t *testing.T) {
 	}
 }
 
+func TestBuild_DenyAllowOverlap_DisjointWildcards(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:Put*"}, []string{"*"}),
+		stmt("Deny", []string{"*:Get*"}, []string{"*"}),
+	)
+
+	g := Build(p)
+
+	if edges := g.EdgesOfType(DenyAllowOverlap); len(edges) != 0 {
+		t.Fatalf("expected no DenyAllowOverlap edge, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_Intersection(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:Get*"}, []string{"*"}),
+		stmt("Deny", []string{"s3:*Object"}, []string{"*"}),
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 DenyAllowOverlap edge, got %d", len(edges))
+	}
+	if got := edges[0].Meta.OverlappingActions; len(got) != 1 || got[0] != "s3:Get*Object" {
+		t.Errorf("expected the overlap to be s3:Get*Object, got %v", got)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_ActionNames(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:*"}, []string{"*"}),
+		stmt("Deny", []string{"*:Get*"}, []string{"*"}),
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 DenyAllowOverlap edge, got %d", len(edges))
+	}
+	if got := edges[0].Meta.OverlappingActions; len(got) != 1 || got[0] != "s3:Get*" {
+		t.Errorf("expected the overlap to be s3:Get*, got %v", got)
+	}
+}
+
+func TestBuild_Subsumes(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/logs/*"}),
+		stmt("Allow", []string{"s3:*"}, []string{"arn:aws:s3:::b/*"}),
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(Subsumes)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 Subsumes edge, got %d", len(edges))
+	}
+	if edges[0].From != 1 || edges[0].To != 0 {
+		t.Errorf("expected edge 1→0, got %d→%d", edges[0].From, edges[0].To)
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("expected no other edges, got %+v", g.Edges())
+	}
+}
+
+func TestSubsumption(t *testing.T) {
+	base := stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/*"})
+
+	withCondition := base
+	withCondition.Condition = model.Condition{"StringEquals": {"aws:PrincipalTag/team": model.NewConditionValue("a")}}
+	if !Subsumption(base, withCondition) {
+		t.Error("a statement without conditions should cover the same statement with one")
+	}
+	if Subsumption(withCondition, base) {
+		t.Error("a conditional statement should not cover an unconditional one")
+	}
+
+	wider := withCondition
+	wider.Condition = model.Condition{"StringEquals": {"aws:PrincipalTag/team": model.NewConditionValue("a", "b")}}
+	if !Subsumption(wider, withCondition) || Subsumption(withCondition, wider) {
+		t.Error("more allowed condition values should cover fewer")
+	}
+
+	deny := base
+	deny.Effect = "Deny"
+	if Subsumption(deny, base) {
+		t.Error("statements with different effects should not subsume each other")
+	}
+
+	public := base
+	public.Principal = &model.Principal{Wildcard: true}
+	account := base
+	account.Principal = &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::123456789012:root"}}}
+	if !Subsumption(public, account) || Subsumption(account, public) {
+		t.Error("a wildcard principal should cover a specific one")
+	}
+}
+
+func TestSubsumption_SpentBudget(t *testing.T) {
+	// With no budget left only the cheap tests run: they still see a
+	// trailing wildcard cover a literal, and claim nothing they cannot see.
+	sv := &solver{}
+	read := stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/logs/*"})
+	if !sv.subsumes(stmt("Allow", []string{"s3:*"}, []string{"arn:aws:s3:::b/*"}), read) {
+		t.Error("expected s3:* on b/* to cover a read of b/logs/*")
+	}
+	if sv.subsumes(stmt("Allow", []string{"s3:Get?"}, []string{"*"}), stmt("Allow", []string{"s3:Get*"}, []string{"*"})) {
+		t.Error("expected s3:Get? not to cover s3:Get*")
+	}
+	if sv.subsumes(stmt("Allow", []string{"*Object"}, []string{"*"}), stmt("Allow", []string{"s3:Get*Object"}, []string{"*"})) {
+		t.Error("expected coverage the prefix test cannot see to be left out")
+	}
+
+	get, put := patternSet{Patterns: []string{"s3:Get*"}}, patternSet{Patterns: []string{"s3:Put*"}}
+	if sv.overlap(get, put, actionNames) {
+		t.Error("expected different literal prefixes not to overlap")
+	}
+	if !sv.overlap(patternSet{Patterns: []string{"*:Get*"}}, put, actionNames) {
+		t.Error("expected an undecided overlap to be assumed")
+	}
+}
+
+func TestUnrestricted(t *testing.T) {
+	all := stmt("Deny", []string{"iam:*"}, []string{"*"})
+	if !Unrestricted(all) {
+		t.Error("expected a statement on * without conditions to be unrestricted")
+	}
+
+	conditional := all
+	conditional.Condition = model.Condition{"Bool": {"aws:MultiFactorAuthPresent": model.NewConditionValue(false)}}
+	scoped := stmt("Deny", []string{"iam:*"}, []string{"arn:aws:iam::*:role/*"})
+	named := all
+	named.Principal = &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::123456789012:root"}}}
+	for name, s := range map[string]model.Statement{"conditional": conditional, "scoped": scoped, "named principal": named} {
+		if Unrestricted(s) {
+			t.Errorf("%s: expected statement not to be unrestricted", name)
+		}
+	}
+}
+
+func TestBuild_ShadowedAllow(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject", "s3:PutObject"}, []string{"arn:aws:s3:::b/logs/*"}),
+		stmt("Deny", []string{"s3:Get*"}, []string{"arn:aws:s3:::b/*"}),
+		stmt("Deny", []string{"s3:*Object"}, []string{"arn:aws:s3:::b/logs/*"}),
+	)
+
+	g := Build(p)
+
+	got := g.Nodes()[0].ShadowedBy
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("expected statement 0 to be shadowed by 1 and 2, got %v", got)
+	}
+}
+
+func TestBuild_ShadowedAllow_PartialCoverage(t *testing.T) {
+	tests := map[string]*model.Policy{
+		"action left over": policyWith(
+			stmt("Allow", []string{"s3:*"}, []string{"*"}),
+			stmt("Deny", []string{"s3:Get*"}, []string{"*"}),
+			stmt("Deny", []string{"s3:Put*"}, []string{"*"}),
+		),
+		"resource left over": policyWith(
+			stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/*"}),
+			stmt("Deny", []string{"s3:*"}, []string{"arn:aws:s3:::b/secret/*"}),
+		),
+		"resources split by action": policyWith(
+			stmt("Allow", []string{"s3:GetObject", "s3:PutObject"}, []string{"arn:aws:s3:::a/*", "arn:aws:s3:::b/*"}),
+			stmt("Deny", []string{"s3:GetObject"}, []string{"arn:aws:s3:::a/*", "arn:aws:s3:::b/*"}),
+			stmt("Deny", []string{"s3:PutObject"}, []string{"arn:aws:s3:::a/*"}),
+		),
+	}
+
+	for name, p := range tests {
+		g := Build(p)
+		if got := g.Nodes()[0].ShadowedBy; len(got) != 0 {
+			t.Errorf("%s: expected statement 0 to stay live, got shadowed by %v", name, got)
+		}
+	}
+}
+
+func TestBuild_ShadowedAllow_ManyDenies(t *testing.T) {
+	stmts := []model.Statement{stmt("Allow", []string{"s3:GetObject"}, []string{"*"})}
+	for i := 0; i < 12; i++ {
+		stmts = append(stmts, stmt("Deny", []string{"s3:Get*"}, []string{fmt.Sprintf("arn:aws:s3:::bucket-%d/*", i)}))
+	}
+	stmts = append(stmts, stmt("Deny", []string{"s3:*"}, []string{"*"}))
+
+	g := Build(policyWith(stmts...))
+
+	if got := g.Nodes()[0].ShadowedBy; len(got) != 13 {
+		t.Errorf("expected statement 0 to be shadowed by all 13 denies, got %v", got)
+	}
+}
+
+func TestBuild_ShadowedAllow_Budget(t *testing.T) {
+	stmts := []model.Statement{stmt("Allow", []string{"s3:*"}, []string{"*"})}
+	for i := 0; i < 24; i++ {
+		stmts = append(stmts, stmt("Deny", []string{fmt.Sprintf("s3:Action%d*", i)}, []string{"*"}))
+	}
+
+	g := Build(policyWith(stmts...))
+
+	if got := g.Nodes()[0].ShadowedBy; len(got) != 0 {
+		t.Errorf("expected statement 0 to stay live, got shadowed by %v", got)
+	}
+}
+
+func TestBuild_ShadowedAllow_SpentBudget(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"*"}),
+		stmt("Deny", []string{"*"}, []string{"*"}),
+	)
+
+	if got := shadowingDenies(newSolver(), p, 0); len(got) != 1 {
+		t.Errorf("expected statement 0 to be shadowed, got %v", got)
+	}
+	if got := shadowingDenies(&solver{}, p, 0); got != nil {
+		t.Errorf("expected no shadowing proven without budget, got %v", got)
+	}
+}
+
+func TestBuild_WildcardPolicy(t *testing.T) {
+	// Every pair of these statements has wildcards on both sides. Searched
+	// exactly, 40 of them took minutes; the shared budget bounds the Build.
+	verbs := []string{"Get", "Put", "List", "*", "Describe"}
+	nouns := []string{"Object", "Bucket", "", "?x"}
+	var stmts []model.Statement
+	for i := 0; i < 150; i++ {
+		effect := "Allow"
+		if i%3 == 0 {
+			effect = "Deny"
+		}
+		var actions, resources []string
+		for k := 0; k < 3; k++ {
+			actions = append(actions, fmt.Sprintf("*:%s*%s*", verbs[(i+k)%len(verbs)], nouns[(i*k)%len(nouns)]))
+			resources = append(resources, fmt.Sprintf("arn:aws:*:*:*:%s*/*%d*", nouns[(i+k)%len(nouns)], k))
+		}
+		s := stmt(effect, actions, resources)
+		if i%4 == 1 {
+			s.Action, s.NotAction = nil, actions
+		}
+		stmts = append(stmts, s)
+	}
+
+	start := time.Now()
+	Build(policyWith(stmts...))
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the budget to bound Build, took %v", elapsed)
+	}
+}
+
+func TestBuild_ShadowedAllow_ConditionalDeny(t *testing.T) {
+	deny := stmt("Deny", []string{"*"}, []string{"*"})
+	deny.Condition = model.Condition{"Bool": {"aws:MultiFactorAuthPresent": model.NewConditionValue("false")}}
+	p := policyWith(stmt("Allow", []string{"s3:GetObject"}, []string{"*"}), deny)
+
+	g := Build(p)
+
+	if got := g.Nodes()[0].ShadowedBy; len(got) != 0 {
+		t.Errorf("a conditional Deny should not shadow an Allow, got %v", got)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_DisjointResources(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::public/*"}),
+		stmt("Deny", []string{"s3:GetObject"}, []string{"arn:aws:s3:::secret/*"}),
+	)
+
+	g := Build(p)
+
+	if edges := g.EdgesOfType(DenyAllowOverlap); len(edges) != 0 {
+		t.Fatalf("expected no DenyAllowOverlap edge, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_Resources(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::data/*"}),
+		stmt("Deny", []string{"s3:*"}, []string{"arn:aws:s3:::data/secret/*"}),
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 DenyAllowOverlap edge, got %d", len(edges))
+	}
+	meta := edges[0].Meta
+	if len(meta.OverlappingResources) != 1 || meta.OverlappingResources[0] != "arn:aws:s3:::data/secret/*" {
+		t.Errorf("unexpected overlapping resources %v", meta.OverlappingResources)
+	}
+	if meta.Conditional {
+		t.Error("expected an unconditional overlap")
+	}
+}
+
+func TestBuild_DenyAllowOverlap_NotResource(t *testing.T) {
+	deny := model.Statement{Effect: "Deny", Action: []string{"s3:GetObject"}, NotResource: []string{"arn:aws:s3:::public/*"}}
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::public/*"}),
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::*"}),
+		deny,
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 || edges[0].From != 1 || edges[0].To != 2 {
+		t.Fatalf("expected only statement 1 to overlap the Deny, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_Conditional(t *testing.T) {
+	deny := stmt("Deny", []string{"s3:*"}, []string{"*"})
+	deny.Condition = model.Condition{"Bool": {"aws:SecureTransport": model.NewConditionValue("false")}}
+	p := policyWith(stmt("Allow", []string{"s3:GetObject"}, []string{"*"}), deny)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 || !edges[0].Meta.Conditional {
+		t.Fatalf("expected a conditional overlap, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_Principals(t *testing.T) {
+	allow := stmt("Allow", []string{"s3:GetObject"}, []string{"*"})
+	allow.Principal = &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111111111111:root"}}}
+	deny := stmt("Deny", []string{"s3:GetObject"}, []string{"*"})
+	deny.Principal = &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::222222222222:root"}}}
+
+	g := Build(policyWith(allow, deny))
+
+	if edges := g.EdgesOfType(DenyAllowOverlap); len(edges) != 0 {
+		t.Fatalf("expected no overlap between different principals, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_AccountPrincipals(t *testing.T) {
+	allow := stmt("Allow", []string{"s3:GetObject"}, []string{"*"})
+	allow.Principal = &model.Principal{Members: map[string][]string{"AWS": {"123456789012"}}}
+	deny := stmt("Deny", []string{"s3:GetObject"}, []string{"*"})
+	deny.Principal = &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::123456789012:root"}}}
+
+	g := Build(policyWith(allow, deny))
+
+	if edges := g.EdgesOfType(DenyAllowOverlap); len(edges) != 1 {
+		t.Fatalf("expected an account ID to overlap its root ARN, got %+v", edges)
+	}
+}
+
+func TestBuild_DenyAllowOverlap_NotPrincipal(t *testing.T) {
+	account := &model.Principal{Members: map[string][]string{"AWS": {"123456789012"}}}
+	other := &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::111111111111:root"}}}
+
+	tests := []struct {
+		name         string
+		allow, notIn *model.Principal
+		want         bool
+	}{
+		{"excluded principal", account, &model.Principal{Members: map[string][]string{"AWS": {"arn:aws:iam::123456789012:root"}}}, false},
+		{"principal left in", other, account, true},
+		{"everyone excluded", account, &model.Principal{Wildcard: true}, false},
+		{"identity policy", nil, account, true},
+	}
+
+	for _, tt := range tests {
+		allow := stmt("Allow", []string{"s3:GetObject"}, []string{"*"})
+		allow.Principal = tt.allow
+		deny := stmt("Deny", []string{"s3:GetObject"}, []string{"*"})
+		deny.NotPrincipal = tt.notIn
+
+		g := Build(policyWith(allow, deny))
+
+		if got := len(g.EdgesOfType(DenyAllowOverlap)) == 1; got != tt.want {
+			t.Errorf("%s: overlap = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuild_NotAction_DenyOverlap(t *testing.T) {
+	deny := model.Statement{Effect: "Deny", NotAction: []string{"iam:*"}, Resource: []string{"*"}}
+	p := policyWith(
+		stmt("Allow", []string{"iam:CreateUser"}, []string{"*"}),
+		stmt("Allow", []string{"s3:GetObject", "iam:PassRole"}, []string{"*"}),
+		deny,
+	)
+
+	g := Build(p)
+
+	edges := g.EdgesOfType(DenyAllowOverlap)
+	if len(edges) != 1 || edges[0].From != 1 {
+		t.Fatalf("expected only statement 1 to overlap the NotAction Deny, got %+v", edges)
+	}
+	if got := edges[0].Meta.OverlappingActions; len(got) != 1 || got[0] != "s3:GetObject" {
+		t.Errorf("expected s3:GetObject to be the overlap, got %v", got)
+	}
+	if got := g.Nodes()[0].ShadowedBy; len(got) != 0 {
+		t.Errorf("statement 0 is outside the Deny, got shadowed by %v", got)
+	}
+}
+
+func TestBuild_NotAction_Shadowed(t *testing.T) {
+	p := policyWith(
+		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/*"}),
+		model.Statement{Effect: "Deny", NotAction: []string{"iam:*"}, NotResource: []string{"arn:aws:s3:::other/*"}},
+	)
+
+	g := Build(p)
+
+	if got := g.Nodes()[0].ShadowedBy; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("expected statement 0 to be shadowed by 1, got %v", got)
+	}
+}
+
+func TestBuild_NotAction_NotMerged(t *testing.T) {
+	p := policyWith(
+		model.Statement{Effect: "Allow", NotAction: []string{"iam:*"}, Resource: []string{"arn:aws:s3:::a/*"}},
+		model.Statement{Effect: "Allow", NotAction: []string{"s3:*"}, Resource: []string{"arn:aws:s3:::b/*"}},
+		model.Statement{Effect: "Allow", NotAction: []string{"iam:*"}, Resource: []string{"arn:aws:s3:::c/*"}},
+	)
+
+	g := Build(p)
+
+	for _, e := range g.EdgesOfType(MergeableResource) {
+		if e.From == 0 && e.To == 1 {
+			t.Errorf("statements excluding different actions should not merge resources")
+		}
+	}
+	if edges := g.EdgesOfType(MergeableResource); len(edges) != 1 || edges[0].From != 0 || edges[0].To != 2 {
+		t.Errorf("expected statements 0 and 2 to be mergeable, got %+v", edges)
+	}
+	if edges := g.EdgesOfType(MergeableAction); len(edges) != 0 {
+		t.Errorf("expected no MergeableAction edges, got %+v", edges)
+	}
+}
+
+func TestSubsumption_Not(t *testing.T) {
+	allButIAM := model.Statement{Effect: "Allow", NotAction: []string{"iam:*"}, Resource: []string{"*"}}
+	allButIAMAndS3 := model.Statement{Effect: "Allow", NotAction: []string{"iam:*", "s3:*"}, Resource: []string{"*"}}
+	read := stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::b/*"})
+	passRole := stmt("Allow", []string{"iam:PassRole"}, []string{"*"})
+
+	if !Subsumption(allButIAM, read) {
+		t.Error("NotAction iam:* should cover s3:GetObject")
+	}
+	if Subsumption(allButIAM, passRole) {
+		t.Error("NotAction iam:* should not cover iam:PassRole")
+	}
+	if !Subsumption(allButIAM, allButIAMAndS3) || Subsumption(allButIAMAndS3, allButIAM) {
+		t.Error("excluding fewer actions should cover excluding more")
+	}
+	if !Subsumption(stmt("Allow", []string{"*"}, []string{"*"}), allButIAM) {
+		t.Error("* should cover any NotAction statement")
+	}
+
+	notSecret := model.Statement{Effect: "Allow", Action: []string{"s3:GetObject"}, NotResource: []string{"arn:aws:s3:::b/secret/*"}}
+	if !Subsumption(notSecret, stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::other/*"})) {
+		t.Error("NotResource b/secret/* should cover other/*")
+	}
+	if Subsumption(notSecret, read) {
+		t.Error("NotResource b/secret/* should not cover b/*")
+	}
+}
+
 func TestBuild_NoEdges(t *testing.T) {
 	p := policyWith(
 		stmt("Allow", []string{"s3:GetObject"}, []string{"arn:aws:s3:::bucket-a/*"}),
@@ -0,0 +1,497 @@
+package graph
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// A glob pattern is read as an automaton whose state i means "the first i
+// bytes of the pattern are matched". A '*' state loops on any byte and may
+// also be skipped; '?' and literals consume one byte. Two patterns intersect
+// when their product automaton can reach the state where both are at the end.
+// Comparisons fold ASCII case, like Match.
+
+// A globDomain restricts the strings considered to a regular language, given
+// as a third automaton run in lockstep: step returns the next state after
+// byte c, or false when c is not allowed there.
+type globDomain struct {
+	step   func(d int, c byte) (int, bool)
+	accept func(d int) bool
+}
+
+var anyString = globDomain{
+	step:   func(d int, c byte) (int, bool) { return 0, true },
+	accept: func(d int) bool { return true },
+}
+
+// actionNames are strings of the form service:Name, where neither part is
+// empty or contains a colon, so '*' in "*:Get*" cannot reach past the
+// service prefix.
+var actionNames = globDomain{
+	step: func(d int, c byte) (int, bool) {
+		switch {
+		case c == ':' && d == 1:
+			return 2, true
+		case c == ':':
+			return 0, false
+		case d == 0 || d == 1:
+			return 1, true
+		default:
+			return 3, true
+		}
+	},
+	accept: func(d int) bool { return d == 3 },
+}
+
+type globState struct{ i, j int }
+
+// Intersect reports whether some string matches both patterns and returns
+// the shortest such string. Bytes that either pattern leaves free are
+// written as 'x'.
+func Intersect(a, b string) (witness string, ok bool) {
+	return intersect(a, b, anyString)
+}
+
+func intersect(a, b string, dom globDomain) (string, bool) {
+	type state struct {
+		globState
+		d int
+	}
+	type step struct {
+		prev state
+		c    byte
+	}
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+
+	start := state{}
+	parent := map[state]step{start: {}}
+	queue := []state{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		if s.i == len(a) && s.j == len(b) && dom.accept(s.d) {
+			var out []byte
+			for cur := s; cur != start; cur = parent[cur].prev {
+				if c := parent[cur].c; c != 0 {
+					out = append(out, c)
+				}
+			}
+			for l, r := 0, len(out)-1; l < r; l, r = l+1, r-1 {
+				out[l], out[r] = out[r], out[l]
+			}
+			return string(out), true
+		}
+
+		visit := func(next state, c byte) {
+			if _, seen := parent[next]; !seen {
+				parent[next] = step{prev: s, c: c}
+				queue = append(queue, next)
+			}
+		}
+
+		// Skipping a '*' consumes nothing.
+		if s.i < len(a) && a[s.i] == '*' {
+			visit(state{globState{s.i + 1, s.j}, s.d}, 0)
+		}
+		if s.j < len(b) && b[s.j] == '*' {
+			visit(state{globState{s.i, s.j + 1}, s.d}, 0)
+		}
+
+		if s.i == len(a) || s.j == len(b) {
+			continue
+		}
+		ca, cb := la[s.i], lb[s.j]
+		var candidates []byte
+		switch {
+		case !isWildcardByte(ca) && !isWildcardByte(cb):
+			if ca == cb {
+				candidates = []byte{a[s.i]}
+			}
+		case !isWildcardByte(ca):
+			candidates = []byte{a[s.i]}
+		case !isWildcardByte(cb):
+			candidates = []byte{b[s.j]}
+		default:
+			candidates = []byte{'x', ':'}
+		}
+
+		next := globState{s.i + 1, s.j + 1}
+		if ca == '*' {
+			next.i = s.i
+		}
+		if cb == '*' {
+			next.j = s.j
+		}
+		for _, c := range candidates {
+			if d, ok := dom.step(s.d, toLower(c)); ok {
+				visit(state{next, d}, c)
+			}
+		}
+	}
+	return "", false
+}
+
+func toLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// maxIntersectionPatterns bounds the size of the union Intersection returns.
+const maxIntersectionPatterns = 32
+
+// Intersection returns glob patterns whose union matches exactly the strings
+// matched by both a and b, or nil when they are disjoint. ok is false when
+// the intersection takes more than maxIntersectionPatterns patterns to
+// describe.
+func Intersection(a, b string) (patterns []string, ok bool) {
+	if _, overlap := Intersect(a, b); !overlap {
+		return nil, true
+	}
+	memo := make(map[globState][]string)
+	out, ok := intersection(a, b, strings.ToLower(a), strings.ToLower(b), globState{}, memo)
+	if !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// intersection builds the patterns for a[i:] ∩ b[j:]. When both start with
+// '*', any common string has the shorter star prefix on one side, so it is
+// in *(a[i+1:] ∩ b[j:]) or in *(a[i:] ∩ b[j+1:]). When only one starts with
+// '*', the star is either empty or takes the first byte the other pattern
+// requires.
+func intersection(a, b, la, lb string, s globState, memo map[globState][]string) ([]string, bool) {
+	if out, ok := memo[s]; ok {
+		return out, true
+	}
+
+	var out []string
+	add := func(prefix string, next globState) bool {
+		rest, ok := intersection(a, b, la, lb, next, memo)
+		if !ok {
+			return false
+		}
+		for _, r := range rest {
+			out = append(out, collapseStars(prefix+r))
+		}
+		return true
+	}
+
+	i, j := s.i, s.j
+	ok := true
+	switch {
+	case i == len(a) && j == len(b):
+		out = []string{""}
+	case i < len(a) && a[i] == '*' && j < len(b) && b[j] == '*':
+		ok = add("*", globState{i + 1, j}) && add("*", globState{i, j + 1})
+	case i < len(a) && a[i] == '*':
+		ok = add("", globState{i + 1, j})
+		if ok && j < len(b) {
+			ok = add(b[j:j+1], globState{i, j + 1})
+		}
+	case j < len(b) && b[j] == '*':
+		ok = add("", globState{i, j + 1})
+		if ok && i < len(a) {
+			ok = add(a[i:i+1], globState{i + 1, j})
+		}
+	case i < len(a) && j < len(b):
+		ca, cb := la[i], lb[j]
+		switch {
+		case ca == '?':
+			ok = add(b[j:j+1], globState{i + 1, j + 1})
+		case cb == '?' || ca == cb:
+			ok = add(a[i:i+1], globState{i + 1, j + 1})
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	out = dedupePatterns(out)
+	if len(out) > maxIntersectionPatterns {
+		return nil, false
+	}
+	if out == nil {
+		out = []string{}
+	}
+	memo[s] = out
+	return out, true
+}
+
+// dedupePatterns drops duplicates and patterns another one in the list
+// already matches, keeping the rest sorted.
+func dedupePatterns(patterns []string) []string {
+	seen := make(map[string]bool, len(patterns))
+	var unique []string
+	for _, p := range patterns {
+		if key := strings.ToLower(p); !seen[key] {
+			seen[key] = true
+			unique = append(unique, p)
+		}
+	}
+
+	var out []string
+	for i, p := range unique {
+		covered := false
+		for j, q := range unique {
+			if i != j && q != p && !hasWildcard(p) && Match(q, p) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func collapseStars(p string) string {
+	for strings.Contains(p, "**") {
+		p = strings.ReplaceAll(p, "**", "*")
+	}
+	return p
+}
+
+func isWildcardByte(c byte) bool {
+	return c == '*' || c == '?'
+}
+
+// maxCoverStates bounds one search in satisfiable.
+const maxCoverStates = 20000
+
+// Covers reports whether every string b matches is matched by at least one
+// of patterns.
+func Covers(patterns []string, b string) bool {
+	return covers(patterns, b, anyString)
+}
+
+func covers(patterns []string, b string, dom globDomain) bool {
+	sat, decided := satisfiable([][]string{{b}}, [][]string{patterns}, dom, nil)
+	return decided && !sat
+}
+
+// satisfiable reports whether some string is matched by a pattern of every
+// set in include and by no pattern of any set in exclude. Each set is run as
+// the set of states its patterns can be in, so one state of the search
+// answers for all of them at once. Free bytes are tried once for every byte
+// the patterns name and once for a byte none of them name.
+//
+// Every state visited is charged to budget, when set, by the work it takes
+// to expand. decided is false when the search ran out of budget or past
+// maxCoverStates before it found an answer.
+func satisfiable(include, exclude [][]string, dom globDomain, budget *int) (sat, decided bool) {
+	sets := make([][]string, 0, len(include)+len(exclude))
+	for _, group := range [][][]string{include, exclude} {
+		for _, patterns := range group {
+			lower := make([]string, len(patterns))
+			for k, p := range patterns {
+				lower[k] = strings.ToLower(p)
+			}
+			sets = append(sets, lower)
+		}
+	}
+
+	alphabet := []byte{':'}
+	named := map[byte]bool{':': true}
+	for _, patterns := range sets {
+		for _, p := range patterns {
+			for i := 0; i < len(p); i++ {
+				if c := p[i]; !isWildcardByte(c) && !named[c] {
+					named[c] = true
+					alphabet = append(alphabet, c)
+				}
+			}
+		}
+	}
+	for _, c := range []byte("xyz0123456789#~") {
+		if !named[c] {
+			alphabet = append(alphabet, c)
+			break
+		}
+	}
+
+	states := newPatternStates(sets)
+	type state struct {
+		d    int
+		bits string
+	}
+	start := states.initial()
+	seen := map[state]bool{{bits: start.key()}: true}
+	queue := []stateSet{start}
+	doms := []int{0}
+	cost := len(alphabet) * len(states.char)
+	for len(queue) > 0 {
+		if len(seen) > maxCoverStates {
+			return false, false
+		}
+		if budget != nil {
+			if *budget <= 0 {
+				return false, false
+			}
+			*budget -= cost
+		}
+		set, d := queue[0], doms[0]
+		queue, doms = queue[1:], doms[1:]
+
+		found := dom.accept(d)
+		for k := range sets {
+			if states.accepts(set, k) != (k < len(include)) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true, true
+		}
+
+		for _, c := range alphabet {
+			nd, ok := dom.step(d, c)
+			if !ok {
+				continue
+			}
+			next := states.step(set, c)
+			alive := true
+			for k := range include {
+				if !states.alive(next, k) {
+					alive = false
+					break
+				}
+			}
+			if !alive {
+				continue
+			}
+			if ns := (state{d: nd, bits: next.key()}); !seen[ns] {
+				seen[ns] = true
+				queue = append(queue, next)
+				doms = append(doms, nd)
+			}
+		}
+	}
+	return false, true
+}
+
+// stateSet holds one bit per pattern offset, see patternStates.
+type stateSet []uint64
+
+func (s stateSet) has(bit int) bool {
+	return s[bit/64]&(1<<(bit%64)) != 0
+}
+
+func (s stateSet) set(bit int) {
+	s[bit/64] |= 1 << (bit % 64)
+}
+
+func (s stateSet) key() string {
+	b := make([]byte, 0, 8*len(s))
+	for _, w := range s {
+		b = binary.LittleEndian.AppendUint64(b, w)
+	}
+	return string(b)
+}
+
+// patternStates numbers every offset of every pattern in a list of sets,
+// so the states all sets are in at once fit in one stateSet. Pattern n of
+// set k starts at bit base[k][n] and is fully matched at that bit plus its
+// length; set k ends before bit limit[k].
+type patternStates struct {
+	sets  [][]string
+	base  [][]int
+	limit []int
+	// char is the pattern byte at each bit; final marks the bits past the
+	// end of a pattern.
+	char  []byte
+	final []bool
+}
+
+func newPatternStates(sets [][]string) *patternStates {
+	ps := &patternStates{sets: sets, base: make([][]int, len(sets)), limit: make([]int, len(sets))}
+	for k, patterns := range sets {
+		for _, p := range patterns {
+			ps.base[k] = append(ps.base[k], len(ps.char))
+			ps.char = append(ps.char, p...)
+			ps.char = append(ps.char, 0)
+			for range p {
+				ps.final = append(ps.final, false)
+			}
+			ps.final = append(ps.final, true)
+		}
+		ps.limit[k] = len(ps.char)
+	}
+	return ps
+}
+
+func (ps *patternStates) empty() stateSet {
+	return make(stateSet, (len(ps.char)+63)/64)
+}
+
+// initial is every pattern at its start.
+func (ps *patternStates) initial() stateSet {
+	set := ps.empty()
+	for _, bases := range ps.base {
+		for _, b := range bases {
+			set.set(b)
+		}
+	}
+	ps.close(set)
+	return set
+}
+
+func (ps *patternStates) step(set stateSet, c byte) stateSet {
+	out := ps.empty()
+	for bit, pc := range ps.char {
+		if ps.final[bit] || !set.has(bit) {
+			continue
+		}
+		switch pc {
+		case '*':
+			out.set(bit)
+		case '?':
+			out.set(bit + 1)
+		default:
+			if pc == c {
+				out.set(bit + 1)
+			}
+		}
+	}
+	ps.close(out)
+	return out
+}
+
+// close adds the states reached by skipping stars. Skipping moves to a
+// higher bit, so one ascending pass follows runs of stars.
+func (ps *patternStates) close(set stateSet) {
+	for bit, pc := range ps.char {
+		if pc == '*' && set.has(bit) {
+			set.set(bit + 1)
+		}
+	}
+}
+
+func (ps *patternStates) accepts(set stateSet, k int) bool {
+	for n, b := range ps.base[k] {
+		if set.has(b + len(ps.sets[k][n])) {
+			return true
+		}
+	}
+	return false
+}
+
+// alive reports whether some pattern of set k can still match.
+func (ps *patternStates) alive(set stateSet, k int) bool {
+	start := 0
+	if k > 0 {
+		start = ps.limit[k-1]
+	}
+	for bit := start; bit < ps.limit[k]; bit++ {
+		if set.has(bit) {
+			return true
+		}
+	}
+	return false
+}
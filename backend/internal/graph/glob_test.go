@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"s3:*Object", "s3:List*", true},
+		{"s3:Get*", "s3:*Object", true},
+		{"s3:*Object", "s3:*Bucket", false},
+		{"s3:Get?", "s3:*tab", false},
+		{"s3:Get??", "s3:*tab", true},
+		{"*a*", "*b*", true},
+		{"a*", "*b", true},
+		{"S3:GET*", "s3:get*", true},
+		{"", "*", true},
+		{"", "?", false},
+	}
+
+	for _, tt := range tests {
+		witness, got := Intersect(tt.a, tt.b)
+		if got != tt.want {
+			t.Errorf("Intersect(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			continue
+		}
+		if got && (!Match(tt.a, witness) || !Match(tt.b, witness)) {
+			t.Errorf("Intersect(%q, %q) witness %q does not match both", tt.a, tt.b, witness)
+		}
+	}
+}
+
+func TestIntersect_ActionNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"*:Get*", "s3:Put*", false},
+		{"*:Get*", "s3:G*", true},
+		{"*", "s3:GetObject", true},
+		{"s3:*", "ec2:*", false},
+		{"s3*", "*Object", true},
+		{"s3:Get?", "s3:Get:", false},
+		{"*", "*", true},
+	}
+
+	for _, tt := range tests {
+		witness, got := intersect(tt.a, tt.b, actionNames)
+		if got != tt.want {
+			t.Errorf("intersect(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			continue
+		}
+		if got && strings.Count(witness, ":") != 1 {
+			t.Errorf("intersect(%q, %q) witness %q is not an action name", tt.a, tt.b, witness)
+		}
+	}
+}
+
+func TestIntersect_ShortestWitness(t *testing.T) {
+	if w, _ := Intersect("s3:Get*", "s3:*Object"); w != "s3:GetObject" {
+		t.Errorf("expected s3:GetObject, got %q", w)
+	}
+	if w, _ := Intersect("*", "s3:?"); w != "s3:x" {
+		t.Errorf("expected s3:x, got %q", w)
+	}
+}
+
+func TestIntersection(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []string
+	}{
+		{"s3:*", "s3:GetObject", []string{"s3:GetObject"}},
+		{"s3:Get*", "s3:*Object", []string{"s3:Get*Object"}},
+		{"a*", "*b", []string{"a*b"}},
+		{"*a*", "*b*", []string{"*a*b*", "*b*a*"}},
+		{"s3:?et*", "s3:G*", []string{"s3:Get*"}},
+		{"s3:*Object", "s3:Put*", []string{"s3:Put*Object"}},
+		{"s3:*Object", "s3:*Bucket", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := Intersection(tt.a, tt.b)
+		if !ok {
+			t.Errorf("Intersection(%q, %q) gave up", tt.a, tt.b)
+			continue
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("Intersection(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIntersection_MatchesBoth(t *testing.T) {
+	a, b := "*a?b*", "*b*a*"
+	patterns, ok := Intersection(a, b)
+	if !ok || len(patterns) == 0 {
+		t.Fatalf("expected an intersection, got %v %v", patterns, ok)
+	}
+	for _, s := range []string{"ab", "xaxb", "bxaxb", "aab", "bab", "axbxa", "ba"} {
+		want := Match(a, s) && Match(b, s)
+		got := false
+		for _, p := range patterns {
+			if Match(p, s) {
+				got = true
+			}
+		}
+		if got != want {
+			t.Errorf("%q: union of %v matches = %v, want %v", s, patterns, got, want)
+		}
+	}
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		b        string
+		want     bool
+	}{
+		{[]string{"*"}, "anything*", true},
+		{[]string{"s3:*"}, "s3:Get*", true},
+		{[]string{"s3:Get*"}, "s3:*", false},
+		{[]string{"arn:aws:s3:::b/*"}, "arn:aws:s3:::b/logs/*", true},
+		{[]string{"arn:aws:s3:::b/logs/*"}, "arn:aws:s3:::b/*", false},
+		{[]string{"s3:Get*", "s3:Put*"}, "s3:?*", false},
+		{[]string{"a*", "b*", "?"}, "?", true},
+		{[]string{"a*", "?*"}, "*", false},
+		{[]string{"", "?*"}, "*", true},
+		{[]string{"*a", "*b"}, "*?", false},
+		{[]string{"s3:*Object", "s3:Get*"}, "s3:GetObject", true},
+		{[]string{"S3:GETOBJECT"}, "s3:getobject", true},
+		// More offsets than one bitset word holds.
+		{[]string{"arn:aws:s3:::bucket-a/*", "arn:aws:s3:::bucket-b/*", "arn:aws:s3:::bucket-c/*"}, "arn:aws:s3:::bucket-b/logs/*", true},
+		{[]string{"arn:aws:s3:::bucket-a/*", "arn:aws:s3:::bucket-b/*", "arn:aws:s3:::bucket-c/*"}, "arn:aws:s3:::bucket-?/*", false},
+		{nil, "x", false},
+	}
+
+	for _, tt := range tests {
+		if got := Covers(tt.patterns, tt.b); got != tt.want {
+			t.Errorf("Covers(%q, %q) = %v, want %v", tt.patterns, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCovers_ActionNames(t *testing.T) {
+	// Every action name has a colon, so *:* is as wide as *.
+	if !covers([]string{"*:*"}, "*", actionNames) {
+		t.Error("expected *:* to cover every action")
+	}
+	if Covers([]string{"*:*"}, "*") {
+		t.Error("expected *:* not to cover every string")
+	}
+	if covers([]string{"s3:*"}, "*3:*", actionNames) {
+		t.Error("expected s3:* not to cover *3:*")
+	}
+}
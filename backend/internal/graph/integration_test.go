@@ -4,7 +4,6 @@ import (
 	"testing"
 
 	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
-	"github.com/Kuba0517/iam-analyzer/internal/model"
 	"github.com/Kuba0517/iam-analyzer/internal/normalizer"
 	"github.com/Kuba0517/iam-analyzer/internal/parser"
 	"github.com/Kuba0517/iam-analyzer/internal/scorer"
@@ -57,7 +56,7 @@ func TestFullPipeline_OverprivilegedPolicy(t *testing.T) {
 	}
 
 	for i := 1; i < len(findings); i++ {
-		if severityRank(findings[i].Severity) > severityRank(findings[i-1].Severity) {
+		if analyzer.SeverityRank(findings[i].Severity) > analyzer.SeverityRank(findings[i-1].Severity) {
 			t.Errorf("findings not sorted: %s before %s", findings[i-1].Severity, findings[i].Severity)
 		}
 	}
@@ -166,16 +165,3 @@ func TestFullPipeline_WildcardOverlapDetection(t *testing.T) {
 		t.Error("expected Deny/Allow overlap finding for s3:* vs s3:DeleteObject")
 	}
 }
-
-func severityRank(s model.Severity) int {
-	switch s {
-	case model.SeverityHigh:
-		return 3
-	case model.SeverityMedium:
-		return 2
-	case model.SeverityLow:
-		return 1
-	default:
-		return 0
-	}
-}
@@ -3,10 +3,61 @@ package graph
 import "strings"
 
 func Match(pattern, value string) bool {
-	return matchDP(strings.ToLower(pattern), strings.ToLower(value))
+	return matchDP(strings.ToLower(pattern), nil, strings.ToLower(value))
 }
 
-func matchDP(pattern, value string) bool {
+func MatchCaseSensitive(pattern, value string) bool {
+	return matchDP(pattern, nil, value)
+}
+
+// MatchEscaped is Match for a pattern in which a backslash makes the byte
+// after it literal, as QuoteMeta writes it.
+func MatchEscaped(pattern, value string) bool {
+	p, literal := unescape(strings.ToLower(pattern))
+	return matchDP(p, literal, strings.ToLower(value))
+}
+
+func MatchEscapedCaseSensitive(pattern, value string) bool {
+	p, literal := unescape(pattern)
+	return matchDP(p, literal, value)
+}
+
+// QuoteMeta escapes the wildcards and backslashes in s, so that MatchEscaped
+// only matches s itself.
+func QuoteMeta(s string) string {
+	if !strings.ContainsAny(s, `*?\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '*' || c == '?' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// unescape drops the escaping backslashes from pattern and marks the bytes
+// they escaped as literal.
+func unescape(pattern string) (string, []bool) {
+	var sb strings.Builder
+	var literal []bool
+	for i := 0; i < len(pattern); i++ {
+		c, lit := pattern[i], false
+		if c == '\\' && i+1 < len(pattern) {
+			i++
+			c, lit = pattern[i], true
+		}
+		sb.WriteByte(c)
+		literal = append(literal, lit)
+	}
+	return sb.String(), literal
+}
+
+// matchDP matches value against pattern, where a byte marked in literal
+// stands for itself even if it is a wildcard. literal may be nil.
+func matchDP(pattern string, literal []bool, value string) bool {
 	p, v := len(pattern), len(value)
 
 	dp := make([][]bool, p+1)
@@ -15,20 +66,32 @@ func matchDP(pattern, value string) bool {
 	}
 	dp[0][0] = true
 
+	// op is the pattern byte, or 0 when it only stands for itself.
+	op := func(i int) byte {
+		if c := pattern[i]; (c == '*' || c == '?') && (literal == nil || !literal[i]) {
+			return c
+		}
+		return 0
+	}
+
 	for i := 1; i <= p; i++ {
-		if pattern[i-1] == '*' {
+		if op(i-1) == '*' {
 			dp[i][0] = dp[i-1][0]
 		}
 	}
 
 	for i := 1; i <= p; i++ {
-		for j := 1; j <= v; j++ {
-			switch pattern[i-1] {
-			case '*':
+		switch op(i - 1) {
+		case '*':
+			for j := 1; j <= v; j++ {
 				dp[i][j] = dp[i-1][j] || dp[i][j-1]
-			case '?':
+			}
+		case '?':
+			for j := 1; j <= v; j++ {
 				dp[i][j] = dp[i-1][j-1]
-			default:
+			}
+		default:
+			for j := 1; j <= v; j++ {
 				dp[i][j] = dp[i-1][j-1] && pattern[i-1] == value[j-1]
 			}
 		}
@@ -37,30 +100,37 @@ func matchDP(pattern, value string) bool {
 	return dp[p][v]
 }
 
+// Overlaps reports whether some IAM action name matches both patterns.
 func Overlaps(a, b string) bool {
-	a = strings.ToLower(a)
-	b = strings.ToLower(b)
-
-	if a == "*" || b == "*" {
-		return true
-	}
+	_, ok := intersect(a, b, actionNames)
+	return ok
+}
 
-	if !hasWildcard(a) {
+// prefixOverlap is a cheap stand-in for Overlaps: patterns overlap unless a
+// literal fails to match the other pattern or their literal prefixes
+// disagree. It may report overlaps that do not exist.
+func prefixOverlap(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	switch {
+	case !hasWildcard(a):
 		return Match(b, a)
-	}
-	if !hasWildcard(b) {
+	case !hasWildcard(b):
 		return Match(a, b)
 	}
+	pa, pb := literalPrefix(a), literalPrefix(b)
+	return strings.HasPrefix(pa, pb) || strings.HasPrefix(pb, pa)
+}
 
-	prefixA := literalPrefix(a)
-	prefixB := literalPrefix(b)
-	if prefixA != "" && prefixB != "" {
-		if !strings.HasPrefix(prefixA, prefixB) && !strings.HasPrefix(prefixB, prefixA) {
-			return false
-		}
+// prefixCovers is a cheap stand-in for Covers with a single pattern: it
+// only sees that a literal matches a, or that a is a literal prefix and a
+// trailing '*' and b starts with that prefix. It may miss real coverage.
+func prefixCovers(a, b string) bool {
+	if !hasWildcard(b) {
+		return Match(a, b)
 	}
-
-	return true
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	prefix, ok := strings.CutSuffix(a, "*")
+	return a == b || ok && !hasWildcard(prefix) && strings.HasPrefix(b, prefix)
 }
 
 func hasWildcard(s string) bool {
@@ -68,10 +138,8 @@ func hasWildcard(s string) bool {
 }
 
 func literalPrefix(pattern string) string {
-	for i, c := range pattern {
-		if c == '*' || c == '?' {
-			return pattern[:i]
-		}
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		return pattern[:i]
 	}
 	return pattern
 }
@@ -68,6 +68,11 @@ func TestOverlaps(t *testing.T) {
 		{"s3:GetObject", "s3:*", true},
 
 		{"s3:Get*", "s3:*Object", true},
+
+		// Action names have a single colon, so "*:Get*" cannot match
+		// s3:Put:Get.
+		{"*:Get*", "s3:Put*", false},
+		{"*:Get*", "s3:G*", true},
 	}
 
 	for _, tt := range tests {
@@ -77,3 +82,56 @@ func TestOverlaps(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchEscaped(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{`arn:aws:s3:::b/\*`, "arn:aws:s3:::b/*", true},
+		{`arn:aws:s3:::b/\*`, "arn:aws:s3:::b/key", false},
+		{`s3:Get\?`, "s3:GetX", false},
+		{`a\\*`, `a\bc`, true},
+		{"arn:aws:s3:::B/*", "arn:aws:s3:::b/key", true},
+		{QuoteMeta(`a*b?c\`) + "*", `a*b?c\d`, true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchEscaped(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("MatchEscaped(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixCovers(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"s3:*", "s3:GetObject", true},
+		{"s3:*", "S3:Get*", true},
+		{"s3:Get*", "s3:*", false},
+		{"s3:Get?", "s3:Get*", false},
+		{"s3:Get*", "s3:Get*", true},
+		{"*Object", "s3:Get*Object", false},
+		{"*", "anything*", true},
+	}
+
+	for _, tt := range tests {
+		if got := prefixCovers(tt.a, tt.b); got != tt.want {
+			t.Errorf("prefixCovers(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := prefixCovers(tt.a, tt.b); got && !Covers([]string{tt.a}, tt.b) {
+			t.Errorf("prefixCovers(%q, %q) claims coverage Covers denies", tt.a, tt.b)
+		}
+	}
+}
+
+func TestMatchCaseSensitive(t *testing.T) {
+	if !MatchCaseSensitive("home/*", "home/Alice") {
+		t.Error("expected home/* to match home/Alice")
+	}
+	if MatchCaseSensitive("home/*", "HOME/alice") {
+		t.Error("expected case-sensitive mismatch")
+	}
+}
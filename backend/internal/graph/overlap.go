@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// patternSet is what a statement's Action or Resource element matches:
+// Patterns, or with Negated, everything except them.
+type patternSet struct {
+	Patterns []string
+	Negated  bool
+}
+
+func actionSet(s model.Statement) patternSet {
+	if len(s.NotAction) > 0 {
+		return patternSet{Patterns: s.NotAction, Negated: true}
+	}
+	return patternSet{Patterns: s.Action}
+}
+
+// resourceSet treats a statement without Resource or NotResource, as in a
+// trust policy, as applying to any resource.
+func resourceSet(s model.Statement) patternSet {
+	switch {
+	case len(s.NotResource) > 0:
+		return patternSet{Patterns: s.NotResource, Negated: true}
+	case len(s.Resource) == 0:
+		return patternSet{Patterns: []string{"*"}}
+	default:
+		return patternSet{Patterns: s.Resource}
+	}
+}
+
+func (s patternSet) equal(o patternSet) bool {
+	return s.Negated == o.Negated && reflect.DeepEqual(s.Patterns, o.Patterns)
+}
+
+// constraints collects membership tests for satisfiable: a string has to be
+// in every set added with in and outside every set added without it.
+type constraints struct {
+	include, exclude [][]string
+}
+
+func (c *constraints) add(s patternSet, in bool) {
+	if in != s.Negated {
+		c.include = append(c.include, s.Patterns)
+	} else {
+		c.exclude = append(c.exclude, s.Patterns)
+	}
+}
+
+// with returns a copy of c with s added, leaving c as it was.
+func (c constraints) with(s patternSet, in bool) constraints {
+	c.include, c.exclude = slices.Clip(c.include), slices.Clip(c.exclude)
+	c.add(s, in)
+	return c
+}
+
+// commonPatterns describes the overlap of two sets. Where both are positive
+// it is the intersection of each overlapping pair of patterns; where one is
+// negated it is the patterns of the other that reach outside the exclusion;
+// where both are negated it is "*".
+func (sv *solver) commonPatterns(a, b patternSet, dom globDomain) []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(patterns ...string) {
+		for _, p := range patterns {
+			if key := strings.ToLower(p); !seen[key] {
+				seen[key] = true
+				result = append(result, p)
+			}
+		}
+	}
+
+	switch {
+	case a.Negated && b.Negated:
+		add("*")
+	case a.Negated || b.Negated:
+		pos, neg := a, b
+		if pos.Negated {
+			pos, neg = b, a
+		}
+		for _, p := range pos.Patterns {
+			c := constraints{include: [][]string{{p}}, exclude: [][]string{neg.Patterns}}
+			if sat, decided := sv.satisfiable(c, dom); sat || !decided {
+				add(p)
+			}
+		}
+	default:
+		for _, x := range a.Patterns {
+			for _, y := range b.Patterns {
+				// The product of the patterns bounds both searches below;
+				// past the budget, name the narrower of an overlapping
+				// pair instead.
+				if !sv.spend((len(x) + 1) * (len(y) + 1) * maxIntersectionPatterns) {
+					if prefixOverlap(x, y) {
+						if hasWildcard(x) && !hasWildcard(y) {
+							add(y)
+						} else {
+							add(x)
+						}
+					}
+					continue
+				}
+				if _, ok := intersect(x, y, dom); !ok {
+					continue
+				}
+				common, ok := Intersection(x, y)
+				if !ok {
+					common = []string{x}
+				}
+				// Intersection works on any string; drop the patterns,
+				// like s3:*:Get*, that match nothing in the domain.
+				for _, p := range common {
+					if _, ok := intersect(p, p, dom); ok {
+						add(p)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// principalsOverlap reports whether some principal is named by both
+// statements. A statement without Principal or NotPrincipal, as in an
+// identity policy, names the caller, whoever it is; NotPrincipal names
+// everyone except its members.
+func (sv *solver) principalsOverlap(a, b model.Statement) bool {
+	switch {
+	case a.NotPrincipal != nil && b.NotPrincipal != nil:
+		// Everyone but a few on both sides still leaves someone.
+		return !a.NotPrincipal.Wildcard && !b.NotPrincipal.Wildcard
+	case a.NotPrincipal != nil:
+		return sv.principalOutside(b.Principal, a.NotPrincipal)
+	case b.NotPrincipal != nil:
+		return sv.principalOutside(a.Principal, b.NotPrincipal)
+	}
+
+	pa, pb := a.Principal, b.Principal
+	if pa == nil || pb == nil || pa.Wildcard || pb.Wildcard {
+		return true
+	}
+	for typ, ids := range pa.Members {
+		if anyOverlap(principalIDs(typ, ids), principalIDs(typ, pb.Members[typ]), stringsOverlap) {
+			return true
+		}
+	}
+	return false
+}
+
+// principalOutside reports whether p names some principal that not does
+// not exclude.
+func (sv *solver) principalOutside(p, not *model.Principal) bool {
+	switch {
+	case not.Wildcard:
+		return false
+	case p == nil || p.Wildcard:
+		return true
+	}
+	for typ, ids := range p.Members {
+		excluded := principalIDs(typ, not.Members[typ])
+		for _, id := range principalIDs(typ, ids) {
+			if !sv.covers(excluded, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// principalIDs puts AWS principals in the form arn.Principal compares them
+// in, so an account ID and its root ARN name the same principal.
+func principalIDs(typ string, ids []string) []string {
+	if typ != "AWS" {
+		return ids
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = arn.Principal(id)
+	}
+	return out
+}
+
+func stringsOverlap(a, b string) bool {
+	_, ok := Intersect(a, b)
+	return ok
+}
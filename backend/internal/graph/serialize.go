@@ -8,13 +8,26 @@ import (
 )
 
 func Serialize(g *Graph, p *model.Policy) model.GraphData {
+	return SerializeWithMapping(g, p, model.IdentityMapping(p))
+}
+
+// SerializeWithMapping is Serialize for a normalized policy. Node indices and
+// edges refer to p; labels and ShadowedBy use statement indices in the
+// original document.
+func SerializeWithMapping(g *Graph, p *model.Policy, m model.StatementMapping) model.GraphData {
 	nodes := make([]model.GraphNode, 0, len(p.Statement))
 	for i, s := range p.Statement {
-		nodes = append(nodes, model.GraphNode{
-			Index:  i,
-			Label:  statementLabel(i, s),
-			Effect: s.Effect,
-		})
+		node := model.GraphNode{
+			Index:         i,
+			OriginalIndex: m.Original(i),
+			Sid:           s.Sid,
+			Label:         statementLabel(m.Original(i), s),
+			Effect:        s.Effect,
+		}
+		if i < len(g.Nodes()) {
+			node.ShadowedBy = m.Originals(g.Nodes()[i].ShadowedBy)
+		}
+		nodes = append(nodes, node)
 	}
 
 	edges := make([]model.GraphEdge, 0, len(g.Edges()))
@@ -31,33 +44,23 @@ func Serialize(g *Graph, p *model.Policy) model.GraphData {
 }
 
 func statementLabel(idx int, s model.Statement) string {
-	actions := s.Action
-	if len(actions) == 0 {
-		actions = s.NotAction
-	}
+	return fmt.Sprintf("S%d: %s %s on %s", idx, s.Effect, setLabel(actionSet(s)), setLabel(resourceSet(s)))
+}
 
-	actionStr := "*"
-	if len(actions) > 0 {
-		actionStr = actions[0]
-		if len(actions) > 1 {
-			actionStr = fmt.Sprintf("%s +%d", actions[0], len(actions)-1)
+// setLabel shows the first pattern of a set, how many more there are, and
+// "all except" for NotAction and NotResource.
+func setLabel(ps patternSet) string {
+	label := "*"
+	if len(ps.Patterns) > 0 {
+		label = ps.Patterns[0]
+		if len(ps.Patterns) > 1 {
+			label = fmt.Sprintf("%s +%d", ps.Patterns[0], len(ps.Patterns)-1)
 		}
 	}
-
-	resources := s.Resource
-	if len(resources) == 0 {
-		resources = s.NotResource
-	}
-
-	resourceStr := "*"
-	if len(resources) > 0 {
-		resourceStr = resources[0]
-		if len(resources) > 1 {
-			resourceStr = fmt.Sprintf("%s +%d", resources[0], len(resources)-1)
-		}
+	if ps.Negated {
+		label = "all except " + label
 	}
-
-	return fmt.Sprintf("S%d: %s %s on %s", idx, s.Effect, actionStr, resourceStr)
+	return label
 }
 
 func edgeLabel(e Edge) string {
@@ -68,11 +71,17 @@ func edgeLabel(e Edge) string {
 		return "Merge actions"
 	case MergeableResource:
 		return "Merge resources"
+	case Subsumes:
+		return "Covers"
 	case DenyAllowOverlap:
+		label := "Deny/Allow overlap"
 		if len(e.Meta.OverlappingActions) > 0 {
-			return fmt.Sprintf("Overlap: %s", strings.Join(e.Meta.OverlappingActions, ", "))
+			label = fmt.Sprintf("Overlap: %s", strings.Join(e.Meta.OverlappingActions, ", "))
+		}
+		if e.Meta.Conditional {
+			label += " (conditional)"
 		}
-		return "Deny/Allow overlap"
+		return label
 	default:
 		return ""
 	}
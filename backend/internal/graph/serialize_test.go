@@ -72,6 +72,22 @@ func TestSerialize_MultipleActions(t *testing.T) {
 	}
 }
 
+func TestSerialize_NotAction(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Deny", NotAction: model.StringOrSlice{"iam:*", "sts:*"}, NotResource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+		},
+	}
+
+	g := Build(p)
+	data := Serialize(g, p)
+
+	if data.Nodes[0].Label != "S0: Deny all except iam:* +1 on all except arn:aws:s3:::b/*" {
+		t.Errorf("unexpected label: %s", data.Nodes[0].Label)
+	}
+}
+
 func TestSerialize_DenyAllowOverlapEdge(t *testing.T) {
 	p := &model.Policy{
 		Version: "2012-10-17",
@@ -97,3 +113,20 @@ func TestSerialize_DenyAllowOverlapEdge(t *testing.T) {
 		t.Error("expected DenyAllowOverlap edge")
 	}
 }
+
+func TestSerializeWithMapping_ShadowedBy(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::bucket/*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+	m := model.StatementMapping{{Normalized: 0, Original: 2}, {Normalized: 1, Original: 0}}
+
+	data := SerializeWithMapping(Build(p), p, m)
+
+	if got := data.Nodes[0].ShadowedBy; len(got) != 1 || got[0] != 0 {
+		t.Errorf("expected node 0 shadowed by original statement 0, got %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// maxShadowChecks bounds the regions searched for one Allow, so one Allow
+// cannot spend the budget of the whole Build. The regions double with every
+// Deny that splits them; past either limit the Allow is left live rather
+// than searched further.
+const maxShadowChecks = 128
+
+// shadowingDenies returns the unconditional Deny statements that together
+// deny every action on every resource the Allow statement at allow grants,
+// or nil when some of it still takes effect.
+//
+// The actions of the Allow are split into regions by which Deny statements
+// name them. Each non-empty region has to have the Allow's resources covered
+// by the resources of the denies that apply there; a region no Deny applies
+// to means the Allow is live.
+func shadowingDenies(sv *solver, p *model.Policy, allow int) []int {
+	a := p.Statement[allow]
+	if a.Effect != "Allow" || (len(a.Action) == 0 && len(a.NotAction) == 0) {
+		return nil
+	}
+	allowActions, allowResources := actionSet(a), resourceSet(a)
+
+	var denies []int
+	for k, d := range p.Statement {
+		if d.Effect != "Deny" || len(d.Condition) > 0 || d.NotPrincipal != nil {
+			continue
+		}
+		if !sv.principalCovers(d.Principal, a.Principal) || hasResources(a) != hasResources(d) {
+			continue
+		}
+		if sv.overlap(allowActions, actionSet(d), actionNames) && sv.overlap(allowResources, resourceSet(d), anyString) {
+			denies = append(denies, k)
+		}
+	}
+	if len(denies) == 0 {
+		return nil
+	}
+
+	search := shadowSearch{sv: sv, p: p, denies: denies, resources: allowResources, used: make(map[int]bool)}
+	var region constraints
+	region.add(allowActions, true)
+	if !search.covered(region, nil, 0) {
+		return nil
+	}
+
+	out := make([]int, 0, len(search.used))
+	for k := range search.used {
+		out = append(out, k)
+	}
+	sort.Ints(out)
+	return out
+}
+
+type shadowSearch struct {
+	sv        *solver
+	p         *model.Policy
+	denies    []int
+	resources patternSet
+	used      map[int]bool
+	checks    int
+}
+
+// covered splits region by whether denies[next:] name it, skipping the
+// branches with no actions left, and reports whether the denies in applied
+// cover the resources of every region that remains.
+func (s *shadowSearch) covered(region constraints, applied []int, next int) bool {
+	if s.checks++; s.checks > maxShadowChecks {
+		return false
+	}
+	sat, decided := s.sv.satisfiable(region, actionNames)
+	switch {
+	case !decided:
+		return false
+	case !sat:
+		return true
+	}
+	if next == len(s.denies) {
+		if len(applied) == 0 {
+			return false
+		}
+		var uncovered constraints
+		uncovered.add(s.resources, true)
+		for _, k := range applied {
+			uncovered.add(resourceSet(s.p.Statement[k]), false)
+		}
+		if sat, decided := s.sv.satisfiable(uncovered, anyString); sat || !decided {
+			return false
+		}
+		for _, k := range applied {
+			s.used[k] = true
+		}
+		return true
+	}
+
+	k := s.denies[next]
+	named := actionSet(s.p.Statement[k])
+	return s.covered(region.with(named, true), append(slices.Clip(applied), k), next+1) &&
+		s.covered(region.with(named, false), applied, next+1)
+}
+
+func anyOverlap(as, bs []string, overlaps func(a, b string) bool) bool {
+	for _, a := range as {
+		for _, b := range bs {
+			if overlaps(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package graph
+
+import "slices"
+
+// maxBuildWork bounds the pattern search one Build spends across all of its
+// checks, in the units satisfiable charges. Past it, checks fall back to the
+// cheap prefix and Match tests.
+const maxBuildWork = 30_000_000
+
+// solver answers the pattern set questions of one Build out of a shared
+// work budget, so the pairwise checks cannot add up to more than
+// maxBuildWork however many statements there are.
+type solver struct {
+	budget int
+}
+
+func newSolver() *solver {
+	return &solver{budget: maxBuildWork}
+}
+
+func (sv *solver) satisfiable(c constraints, dom globDomain) (sat, decided bool) {
+	if sv.budget <= 0 {
+		return false, false
+	}
+	return satisfiable(c.include, c.exclude, dom, &sv.budget)
+}
+
+// spend charges n to the budget and reports whether there was any left.
+func (sv *solver) spend(n int) bool {
+	if sv.budget <= 0 {
+		return false
+	}
+	sv.budget -= n
+	return true
+}
+
+// overlap reports whether some string is in both a and b. Undecided, it
+// assumes they overlap unless their literals clearly disagree.
+func (sv *solver) overlap(a, b patternSet, dom globDomain) bool {
+	var c constraints
+	c.add(a, true)
+	c.add(b, true)
+	if sat, decided := sv.satisfiable(c, dom); decided {
+		return sat
+	}
+	return a.Negated || b.Negated || anyOverlap(a.Patterns, b.Patterns, prefixOverlap)
+}
+
+// contains reports whether everything in b is also in a. Undecided, it only
+// accepts what prefixCovers can see.
+func (sv *solver) contains(a, b patternSet, dom globDomain) bool {
+	var c constraints
+	c.add(b, true)
+	c.add(a, false)
+	if sat, decided := sv.satisfiable(c, dom); decided {
+		return !sat
+	}
+	switch {
+	case a.Negated:
+		return false
+	case b.Negated:
+		return slices.Contains(a.Patterns, "*")
+	default:
+		return coversAll(a.Patterns, b.Patterns, anyPrefixCovers)
+	}
+}
+
+func (sv *solver) covers(patterns []string, b string) bool {
+	return sv.contains(patternSet{Patterns: patterns}, patternSet{Patterns: []string{b}}, anyString)
+}
+
+func anyPrefixCovers(patterns []string, b string) bool {
+	for _, p := range patterns {
+		if prefixCovers(p, b) {
+			return true
+		}
+	}
+	return false
+}
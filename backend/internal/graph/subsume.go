@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Subsumption reports whether statement a applies to every request b applies
+// to, with the same effect: its actions, resources and principals cover b's
+// and its conditions are no stricter. NotAction and NotResource are read as
+// the complement of what they list.
+func Subsumption(a, b model.Statement) bool {
+	return newSolver().subsumes(a, b)
+}
+
+// Unrestricted reports whether s applies to every resource and principal
+// without conditions, so a Deny like it removes its actions outright.
+func Unrestricted(s model.Statement) bool {
+	if len(s.Condition) > 0 || len(s.NotResource) > 0 || s.NotPrincipal != nil {
+		return false
+	}
+	if s.Principal != nil && !s.Principal.Wildcard {
+		return false
+	}
+	return slices.Contains(s.Resource, "*")
+}
+
+func (sv *solver) subsumes(a, b model.Statement) bool {
+	if a.Effect != b.Effect {
+		return false
+	}
+	if len(b.Action) == 0 && len(b.NotAction) == 0 {
+		return false
+	}
+	if !sv.contains(actionSet(a), actionSet(b), actionNames) {
+		return false
+	}
+	// A statement without resources, as in a trust policy, is only
+	// compared with another one.
+	if hasResources(a) != hasResources(b) || !sv.contains(resourceSet(a), resourceSet(b), anyString) {
+		return false
+	}
+	if !sv.principalCovers(a.Principal, b.Principal) || !reflect.DeepEqual(a.NotPrincipal, b.NotPrincipal) {
+		return false
+	}
+	return conditionCovers(a.Condition, b.Condition)
+}
+
+func hasResources(s model.Statement) bool {
+	return len(s.Resource) > 0 || len(s.NotResource) > 0
+}
+
+func coversAll(patterns, values []string, covers func([]string, string) bool) bool {
+	for _, v := range values {
+		if !covers(patterns, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sv *solver) principalCovers(a, b *model.Principal) bool {
+	switch {
+	case a == nil || b == nil:
+		return a == nil && b == nil
+	case a.Wildcard:
+		return true
+	case b.Wildcard:
+		return false
+	}
+	for typ, ids := range b.Members {
+		if !coversAll(principalIDs(typ, a.Members[typ]), principalIDs(typ, ids), sv.covers) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionCovers reports whether condition a is met whenever b is. Every
+// test in a must also be in b. A positive operator with several values
+// passes when any of them matches, so b may list fewer; negated and
+// ForAllValues operators have to list the same values.
+func conditionCovers(a, b model.Condition) bool {
+	for op, keys := range a {
+		for key, av := range keys {
+			bv, ok := b[op][key]
+			if !ok {
+				return false
+			}
+			want, have := av.Strings(), bv.Strings()
+			if strings.Contains(op, "Not") || strings.HasPrefix(op, "ForAllValues:") {
+				if !sameValues(want, have) {
+					return false
+				}
+			} else if !subsetOf(have, want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func subsetOf(values, of []string) bool {
+	set := make(map[string]bool, len(of))
+	for _, v := range of {
+		set[v] = true
+	}
+	for _, v := range values {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValues(a, b []string) bool {
+	return subsetOf(a, b) && subsetOf(b, a)
+}
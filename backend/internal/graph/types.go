@@ -7,6 +7,9 @@ const (
 	MergeableAction
 	MergeableResource
 	DenyAllowOverlap
+
+	// Subsumes points from a statement to one it fully covers.
+	Subsumes
 )
 
 func (e EdgeType) String() string {
@@ -19,6 +22,8 @@ func (e EdgeType) String() string {
 		return "MergeableResource"
 	case DenyAllowOverlap:
 		return "DenyAllowOverlap"
+	case Subsumes:
+		return "Subsumes"
 	default:
 		return "Unknown"
 	}
@@ -27,6 +32,10 @@ func (e EdgeType) String() string {
 type Node struct {
 	Index       int
 	Fingerprint string
+
+	// ShadowedBy lists the Deny statements that together override every
+	// request an Allow statement grants. It is empty for live statements.
+	ShadowedBy []int
 }
 
 type Edge struct {
@@ -37,5 +46,10 @@ type Edge struct {
 }
 
 type EdgeMeta struct {
-	OverlappingActions []string
+	OverlappingActions   []string
+	OverlappingResources []string
+
+	// Conditional is set on a DenyAllowOverlap when the Deny has conditions
+	// the Allow does not, so it only overrides the Allow some of the time.
+	Conditional bool
 }
@@ -2,17 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
 
-	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
-	"github.com/Kuba0517/iam-analyzer/internal/diff"
-	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+	"github.com/Kuba0517/iam-analyzer/internal/evaluator"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
-	"github.com/Kuba0517/iam-analyzer/internal/normalizer"
 	"github.com/Kuba0517/iam-analyzer/internal/parser"
-	"github.com/Kuba0517/iam-analyzer/internal/scorer"
-	"github.com/Kuba0517/iam-analyzer/internal/simplifier"
+	"github.com/Kuba0517/iam-analyzer/internal/permissions"
+	"github.com/Kuba0517/iam-analyzer/internal/pipeline"
 )
 
 func Healthz(w http.ResponseWriter, r *http.Request) {
@@ -29,35 +31,104 @@ func Analyze(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	policy, err := parser.Parse(body)
+	opts := formatOptions(r)
+	if s := r.URL.Query().Get("strict"); s != "" {
+		strict, err := strconv.ParseBool(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid strict parameter: "+s)
+			return
+		}
+		if strict {
+			opts = append(opts, parser.WithStrict())
+		}
+	}
+
+	resp, err := pipeline.Analyze(body, opts...)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AnalyzeTemplate analyzes the policies of a CloudFormation template, given as
+// JSON or YAML.
+func AnalyzeTemplate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(body) > parser.MaxInputBytes {
+		writeError(w, http.StatusBadRequest, parser.ErrInputTooLarge.Error())
+		return
+	}
+
+	resp, err := pipeline.AnalyzeTemplate(body)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	normalized := normalizer.Normalize(policy)
-	score := scorer.Score(normalized)
-	findings := analyzer.Analyze(normalized)
-	suggestions := simplifier.Suggest(normalized)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
 
-	for i := range suggestions {
-		result := suggestions[i].Apply(normalized)
-		preview, err := diff.Unified("normalized", normalized, "simplified", result)
-		if err == nil {
-			suggestions[i].DiffPreview = preview
-		}
+// AnalyzeTerraform analyzes the policies of terraform show -json output or of
+// the aws_iam_policy_document data sources in an HCL file.
+func AnalyzeTerraform(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
 	}
+	defer r.Body.Close()
 
-	g := graph.Build(normalized)
-	graphData := graph.Serialize(g, normalized)
+	if len(body) > parser.MaxInputBytes {
+		writeError(w, http.StatusBadRequest, parser.ErrInputTooLarge.Error())
+		return
+	}
 
-	resp := model.AnalyzeResponse{
-		Original:    policy,
-		Normalized:  normalized,
-		Score:       score,
-		Findings:    findings,
-		Suggestions: suggestions,
-		Graph:       &graphData,
+	filename := r.URL.Query().Get("filename")
+	if filename == "" {
+		filename = "main.tf"
+	}
+	resp, err := pipeline.AnalyzeTerraform(body, filename)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AnalyzeAccount analyzes every entity of an aws iam
+// get-account-authorization-details dump.
+func AnalyzeAccount(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(body) > parser.MaxInputBytes {
+		writeError(w, http.StatusBadRequest, parser.ErrInputTooLarge.Error())
+		return
+	}
+
+	resp, err := pipeline.AnalyzeAccount(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -83,29 +154,184 @@ func Apply(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "missing policy")
 		return
 	}
+	if req.Format != "" && req.Format != "json" && req.Format != "yaml" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", req.Format))
+		return
+	}
 
-	normalized := normalizer.Normalize(req.Policy)
-	suggestions := simplifier.Suggest(normalized)
-	simplified := simplifier.Apply(normalized, suggestions, req.PatchIDs)
+	resp := pipeline.Apply(req.Policy, req.PatchIDs)
+	if req.Format == "yaml" {
+		out, err := parser.MarshalYAML(resp.Simplified)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to encode YAML: "+err.Error())
+			return
+		}
+		resp.SimplifiedYAML = string(out)
+	}
 
-	score := scorer.Score(simplified)
-	findings := analyzer.Analyze(simplified)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
 
-	g := graph.Build(simplified)
-	graphData := graph.Serialize(g, simplified)
+func Simulate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
 
-	resp := model.ApplyResponse{
-		Simplified: simplified,
-		Score:      score,
-		Findings:   findings,
-		Graph:      &graphData,
+	var req model.SimulateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if missing(req.Policy) {
+		writeError(w, http.StatusBadRequest, "missing policy")
+		return
+	}
+	if req.Request.Action == "" || req.Request.Resource == "" {
+		writeError(w, http.StatusBadRequest, "request must have action and resource")
+		return
+	}
+
+	policy, err := pipeline.Parse(req.Policy, parser.WithFormat(parser.FormatJSON))
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+
+	resp := evaluator.Evaluate(policy, req.Request)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func Evaluate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var req model.EvaluateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if len(req.Policies) == 0 {
+		writeError(w, http.StatusBadRequest, "missing policies")
+		return
+	}
+	for i, tp := range req.Policies {
+		if missing(tp.Policy) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("policy %d is missing its document", i))
+			return
+		}
+		if !evaluator.ValidPolicyType(tp.Type) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("policy %d has unknown type %q", i, tp.Type))
+			return
+		}
+	}
+	if req.Request.Action == "" || req.Request.Resource == "" {
+		writeError(w, http.StatusBadRequest, "request must have action and resource")
+		return
 	}
 
+	var set model.PolicySet
+	for i, tp := range req.Policies {
+		policy, err := pipeline.Parse(tp.Policy, parser.WithFormat(parser.FormatJSON))
+		if err != nil {
+			writeParseError(w, fmt.Errorf("policy %d: %w", i, err))
+			return
+		}
+		set.Policies = append(set.Policies, model.TypedPolicy{Type: tp.Type, Name: tp.Name, Policy: policy})
+	}
+
+	resp := evaluator.EvaluateSet(set, req.Request)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(resp)
 }
 
+func Permissions(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, parser.MaxInputBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	policy, err := parser.Parse(body, formatOptions(r)...)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+
+	resp := permissions.Enumerate(policy, catalog.Default())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// missing reports whether a policy field of a request was left out or null.
+func missing(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// formatOptions honours a JSON or YAML Content-Type. Anything else, including
+// a missing header, leaves the parser to detect the format.
+func formatOptions(r *http.Request) []parser.Option {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return []parser.Option{parser.WithFormat(parser.FormatJSON)}
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return []parser.Option{parser.WithFormat(parser.FormatYAML)}
+	default:
+		return nil
+	}
+}
+
+// writeParseError adds the input position to the error body when the parser
+// knows it, so clients can highlight the offending line. A document that
+// failed validation is answered with 422 and every issue found in it.
+func writeParseError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	body := map[string]any{"error": err.Error()}
+
+	var pe *parser.ParseError
+	var ve *parser.ValidationError
+	if errors.As(err, &ve) {
+		status = http.StatusUnprocessableEntity
+		body["issues"] = ve.Issues
+		pe = ve.First()
+	} else {
+		errors.As(err, &pe)
+	}
+
+	if pe != nil && pe.Position.Line > 0 {
+		body["position"] = pe.Position
+		if pe.Statement >= 0 {
+			body["statement"] = pe.Statement
+		}
+		if pe.Field != "" {
+			body["field"] = pe.Field
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
 func writeError(w http.ResponseWriter, status int, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -65,6 +65,29 @@ func TestAnalyze_HappyPath(t *testing.T) {
 	}
 }
 
+func TestAnalyze_YAML(t *testing.T) {
+	policy := "Version: 2012-10-17\nStatement:\n  - Effect: Allow\n    Action: \"*\"\n    Resource: \"*\"\n"
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	req.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+
+	handler.Analyze(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp model.AnalyzeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, f := range resp.Findings {
+		if f.Title == "Full wildcard statement" && len(f.Locations) == 1 && f.Locations[0].Start.Line == 3 {
+			return
+		}
+	}
+	t.Errorf("expected a full wildcard finding on YAML line 3, got %+v", resp.Findings)
+}
+
 func TestAnalyze_InvalidJSON(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{not json`))
 	w := httptest.NewRecorder()
@@ -82,6 +105,104 @@ func TestAnalyze_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestAnalyze_ErrorPosition(t *testing.T) {
+	policy := "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [\n    {\"Effect\": \"Alow\", \"Action\": \"s3:*\", \"Resource\": \"*\"}\n  ]\n}"
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+
+	handler.Analyze(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+
+	var body struct {
+		Error     string         `json:"error"`
+		Position  model.Position `json:"position"`
+		Statement *int           `json:"statement"`
+		Field     string         `json:"field"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Position.Line != 4 || body.Position.Column != 16 || body.Field != "Effect" || body.Statement == nil || *body.Statement != 0 {
+		t.Errorf("unexpected error body: %s", w.Body.String())
+	}
+	if !strings.HasPrefix(body.Error, "line 4, column 16:") {
+		t.Errorf("expected position in message, got %q", body.Error)
+	}
+}
+
+func TestAnalyze_FindingLocations(t *testing.T) {
+	policy := `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Deny", "Action": "s3:DeleteBucket", "Resource": "arn:aws:s3:::b"},
+    {"Effect": "Allow", "Action": "*", "Resource": "*"}
+  ]
+}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+
+	handler.Analyze(w, req)
+
+	var resp model.AnalyzeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, f := range resp.Findings {
+		if f.Title != "Full wildcard statement" {
+			continue
+		}
+		if len(f.Locations) != 1 || f.Locations[0].Statement != 1 || f.Locations[0].Start.Line != 5 {
+			t.Errorf("expected location of original statement 1 on line 5, got %+v", f.Locations)
+		}
+		return
+	}
+	t.Fatal("expected a full wildcard finding")
+}
+
+func TestAnalyze_OriginalIndices(t *testing.T) {
+	policy := `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Sid": "Guard", "Effect": "Deny", "Action": "s3:DeleteBucket", "Resource": "arn:aws:s3:::b"},
+    {"Sid": "Admin", "Effect": "Allow", "Action": "*", "Resource": "*"}
+  ]
+}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+
+	handler.Analyze(w, req)
+
+	var resp model.AnalyzeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := model.StatementMapping{{Normalized: 0, Original: 1, Sid: "Admin"}, {Normalized: 1, Original: 0, Sid: "Guard"}}
+	if len(resp.Mapping) != len(want) || resp.Mapping[0] != want[0] || resp.Mapping[1] != want[1] {
+		t.Fatalf("unexpected mapping %+v", resp.Mapping)
+	}
+	if n := resp.Graph.Nodes[0]; n.OriginalIndex != 1 || n.Sid != "Admin" || !strings.HasPrefix(n.Label, "S1:") {
+		t.Errorf("unexpected graph node %+v", n)
+	}
+
+	for _, f := range resp.Findings {
+		if f.Title != "Full wildcard statement" {
+			continue
+		}
+		if len(f.StmtIndices) != 1 || f.StmtIndices[0] != 0 || len(f.OriginalIndices) != 1 || f.OriginalIndices[0] != 1 {
+			t.Errorf("expected normalized 0 and original 1, got %v and %v", f.StmtIndices, f.OriginalIndices)
+		}
+		if f.Evidence != "Statement 1 (Admin) has Action=* and Resource=*" {
+			t.Errorf("unexpected evidence %q", f.Evidence)
+		}
+		return
+	}
+	t.Fatal("expected a full wildcard finding")
+}
+
 func TestAnalyze_MissingVersion(t *testing.T) {
 	policy := `{"Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`
 	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
@@ -89,8 +210,66 @@ func TestAnalyze_MissingVersion(t *testing.T) {
 
 	handler.Analyze(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", w.Code)
+	}
+}
+
+func TestAnalyze_AllValidationIssues(t *testing.T) {
+	policy := `{"Version": "2008-10-17", "Statement": [
+		{"Sid": "A", "Effect": "Permit", "Action": "s3:GetObject", "Resource": "*"},
+		{"Sid": "B", "Effect": "Allow", "Action": 42, "Resource": "*"},
+		{"Sid": "C", "Effect": "Allow", "Resource": "*"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+
+	handler.Analyze(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+
+	var body struct {
+		Issues []model.Issue `json:"issues"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	codes := make(map[string]model.Issue)
+	for _, is := range body.Issues {
+		codes[is.Code] = is
+	}
+	for code, stmt := range map[string]int{"invalid-json": 1, "invalid-effect": 0, "missing-action": 2, "legacy-version": -1} {
+		is, ok := codes[code]
+		if !ok || is.Statement != stmt {
+			t.Errorf("expected %s on statement %d, got %+v", code, stmt, body.Issues)
+		}
+	}
+	if codes["legacy-version"].Level != model.IssueWarning || codes["invalid-effect"].Level != model.IssueError {
+		t.Errorf("unexpected levels: %+v", body.Issues)
+	}
+}
+
+func TestAnalyze_Strict(t *testing.T) {
+	policy := `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*", "Conditions": {}}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+	handler.Analyze(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 without strict mode, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/analyze?strict=true", strings.NewReader(policy))
+	w = httptest.NewRecorder()
+	handler.Analyze(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 in strict mode, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "unknown-key") {
+		t.Errorf("expected an unknown-key issue, got %s", w.Body.String())
 	}
 }
 
@@ -123,6 +302,132 @@ func TestAnalyze_WithFindings(t *testing.T) {
 	}
 }
 
+func TestAnalyzeTemplate(t *testing.T) {
+	template := `Resources:
+  Policy:
+    Type: AWS::IAM::ManagedPolicy
+    Properties:
+      PolicyDocument:
+        Version: 2012-10-17
+        Statement:
+          - Effect: Allow
+            Action: "*"
+            Resource: !Sub "arn:aws:s3:::${Bucket}"
+  Broken:
+    Type: AWS::IAM::Policy
+    Properties:
+      PolicyDocument:
+        Statement:
+          - Effect: Allow
+            Action: s3:GetObject
+            Resource: "*"
+`
+	req := httptest.NewRequest(http.MethodPost, "/analyze/cloudformation", strings.NewReader(template))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeTemplate(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp model.TemplateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Policies) != 2 {
+		t.Fatalf("expected 2 policies, got %+v", resp.Policies)
+	}
+	broken, policy := resp.Policies[0], resp.Policies[1]
+	if broken.LogicalID != "Broken" || broken.Error == "" || len(broken.Issues) == 0 {
+		t.Errorf("expected Broken to report its validation issues, got %+v", broken)
+	}
+	if policy.LogicalID != "Policy" || policy.Analysis == nil || len(policy.Analysis.Findings) == 0 {
+		t.Errorf("expected Policy to be analyzed, got %+v", policy)
+	}
+}
+
+func TestAnalyzeTerraform(t *testing.T) {
+	config := `data "aws_iam_policy_document" "admin" {
+  statement {
+    actions   = ["*"]
+    resources = ["*"]
+  }
+}
+`
+	req := httptest.NewRequest(http.MethodPost, "/analyze/terraform?filename=iam.tf", strings.NewReader(config))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeTerraform(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp model.TerraformResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Policies) != 1 {
+		t.Fatalf("expected 1 policy, got %+v", resp.Policies)
+	}
+	p := resp.Policies[0]
+	if p.Address != "data.aws_iam_policy_document.admin" || p.File != "iam.tf" || p.Line != 1 {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	if p.Analysis == nil || len(p.Analysis.Findings) == 0 {
+		t.Errorf("expected findings for an admin policy, got %+v", p)
+	}
+}
+
+func TestAnalyzeAccount(t *testing.T) {
+	dump := `{
+		"UserDetailList": [{"UserName": "alice", "Arn": "arn:aws:iam::123456789012:user/alice",
+			"UserPolicyList": [
+				{"PolicyName": "broken", "PolicyDocument": {"Statement": []}},
+				{"PolicyName": "copy", "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}}
+			],
+			"AttachedManagedPolicies": [{"PolicyName": "Admin", "PolicyArn": "arn:aws:iam::123456789012:policy/Admin"}]}],
+		"GroupDetailList": [],
+		"RoleDetailList": [],
+		"Policies": [{"PolicyName": "Admin", "Arn": "arn:aws:iam::123456789012:policy/Admin", "DefaultVersionId": "v1",
+			"PolicyVersionList": [{"VersionId": "v1", "IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}}]}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/analyze/account", strings.NewReader(dump))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeAccount(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp model.AccountResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Entities) != 2 {
+		t.Fatalf("expected a user and a policy, got %+v", resp.Entities)
+	}
+	alice := resp.Entities[0]
+	if alice.Name != "alice" || len(alice.Policies) != 3 || alice.Policies[0].Error == "" {
+		t.Errorf("expected the broken inline policy to be reported, got %+v", alice.Policies)
+	}
+	for _, p := range alice.Policies[1:] {
+		if p.Analysis == nil || len(p.Analysis.Findings) == 0 {
+			t.Errorf("expected %s to be analyzed on its own, got %+v", p.Name, p)
+		}
+	}
+	if len(alice.Statements) != 2 || alice.Statements[0].Policy != 1 || alice.Statements[1].Policy != 2 {
+		t.Errorf("unexpected statement origins %+v", alice.Statements)
+	}
+
+	combined := alice.Analysis
+	if combined == nil || len(combined.Suggestions) != 0 {
+		t.Fatalf("expected a combined analysis without suggestions, got %+v", combined)
+	}
+	if len(combined.Findings) != 1 || combined.Findings[0].RuleID != "redundant-statement" {
+		t.Errorf("expected only the finding across both policies, got %+v", combined.Findings)
+	}
+}
+
 func TestApply_HappyPath(t *testing.T) {
 	body := `{
 		"policy": {
@@ -157,6 +462,33 @@ func TestApply_HappyPath(t *testing.T) {
 	}
 }
 
+func TestApply_YAML(t *testing.T) {
+	body := `{
+		"policy": {
+			"Version": "2012-10-17",
+			"Statement": [
+				{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["*"]},
+				{"Effect": "Allow", "Action": ["s3:GetObject"], "Resource": ["*"]}
+			]
+		},
+		"patchIds": ["dedup-0"],
+		"format": "yaml"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/apply", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Apply(w, req)
+
+	var resp model.ApplyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.Contains(resp.SimplifiedYAML, "- Effect: Allow") {
+		t.Errorf("expected the simplified policy as YAML, got %q", resp.SimplifiedYAML)
+	}
+}
+
 func TestApply_InvalidJSON(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/apply", strings.NewReader(`{bad`))
 	w := httptest.NewRecorder()
@@ -179,3 +511,165 @@ func TestApply_MissingPolicy(t *testing.T) {
 		t.Errorf("expected 400, got %d", w.Code)
 	}
 }
+
+func TestSimulate_HappyPath(t *testing.T) {
+	body := `{
+		"policy": {
+			"Version": "2012-10-17",
+			"Statement": [
+				{"Effect": "Allow", "Action": "s3:Get*", "Resource": "arn:aws:s3:::logs/*"}
+			]
+		},
+		"request": {"action": "s3:GetObject", "resource": "arn:aws:s3:::logs/2024/a.gz"}
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Simulate(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp model.SimulateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Decision != model.DecisionAllow {
+		t.Errorf("expected Allow, got %s", resp.Decision)
+	}
+}
+
+func TestSimulate_MissingAction(t *testing.T) {
+	body := `{
+		"policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]},
+		"request": {"resource": "arn:aws:s3:::logs/a.gz"}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Simulate(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestSimulate_InvalidPolicy(t *testing.T) {
+	body := `{
+		"policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Alow", "Action": "s3:*", "Resource": "*"}]},
+		"request": {"action": "s3:GetObject", "resource": "arn:aws:s3:::logs/a.gz"}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Simulate(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Issues []model.Issue `json:"issues"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Issues) == 0 || resp.Issues[0].Field != "Effect" {
+		t.Errorf("expected an Effect issue, got %+v", resp.Issues)
+	}
+}
+
+func TestEvaluate_HappyPath(t *testing.T) {
+	body := `{
+		"policies": [
+			{"type": "identity", "policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}},
+			{"type": "permissionBoundary", "name": "boundary", "policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:List*", "Resource": "*"}]}}
+		],
+		"request": {"action": "s3:GetObject", "resource": "arn:aws:s3:::logs/a.gz"}
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Evaluate(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp model.EvaluateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Decision != model.DecisionImplicitDeny || resp.DeniedBy != model.PolicyTypePermissionBoundary {
+		t.Errorf("expected implicit deny by permission boundary, got %s by %q", resp.Decision, resp.DeniedBy)
+	}
+}
+
+func TestEvaluate_UnknownPolicyType(t *testing.T) {
+	body := `{
+		"policies": [{"type": "bucket", "policy": {"Version": "2012-10-17", "Statement": []}}],
+		"request": {"action": "s3:GetObject", "resource": "*"}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Evaluate(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestEvaluate_InvalidPolicy(t *testing.T) {
+	body := `{
+		"policies": [
+			{"type": "identity", "policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}},
+			{"type": "scp", "policy": {"Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}}
+		],
+		"request": {"action": "s3:GetObject", "resource": "*"}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Evaluate(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(resp.Error, "policy 1: ") {
+		t.Errorf("expected the error to name policy 1, got %q", resp.Error)
+	}
+}
+
+func TestPermissions_HappyPath(t *testing.T) {
+	policy := `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/permissions", strings.NewReader(policy))
+	w := httptest.NewRecorder()
+
+	handler.Permissions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp model.PermissionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.GrantedCount == 0 {
+		t.Error("expected s3:Get* to grant catalog actions")
+	}
+	for _, g := range resp.Granted {
+		if g.Service != "s3" {
+			t.Errorf("unexpected service %s", g.Service)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import "fmt"
+
+// StatementRef ties a statement of the normalized policy to the statement of
+// the original document it was produced from.
+type StatementRef struct {
+	Normalized int    `json:"normalized"`
+	Original   int    `json:"original"`
+	Sid        string `json:"sid,omitempty"`
+}
+
+// StatementMapping is indexed by normalized statement index.
+type StatementMapping []StatementRef
+
+// IdentityMapping is the mapping of a policy that was not reordered.
+func IdentityMapping(p *Policy) StatementMapping {
+	m := make(StatementMapping, len(p.Statement))
+	for i, s := range p.Statement {
+		m[i] = StatementRef{Normalized: i, Original: i, Sid: s.Sid}
+	}
+	return m
+}
+
+// Original returns the original index of normalized statement i, or i itself
+// when the mapping does not cover it.
+func (m StatementMapping) Original(i int) int {
+	if i < 0 || i >= len(m) {
+		return i
+	}
+	return m[i].Original
+}
+
+func (m StatementMapping) Originals(indices []int) []int {
+	if indices == nil {
+		return nil
+	}
+	result := make([]int, len(indices))
+	for i, idx := range indices {
+		result[i] = m.Original(idx)
+	}
+	return result
+}
+
+// Ref formats normalized statement i the way the user wrote it: its original
+// index, followed by its Sid when it has one.
+func (m StatementMapping) Ref(i int) string {
+	if i < 0 || i >= len(m) || m[i].Sid == "" {
+		return fmt.Sprint(m.Original(i))
+	}
+	return fmt.Sprintf("%d (%s)", m[i].Original, m[i].Sid)
+}
@@ -0,0 +1,23 @@
+package model
+
+type ActionGroup struct {
+	Service     string   `json:"service"`
+	AccessLevel string   `json:"accessLevel"`
+	Actions     []string `json:"actions"`
+}
+
+type StatementPermissions struct {
+	Index       int           `json:"index"`
+	Effect      string        `json:"effect"`
+	ActionCount int           `json:"actionCount"`
+	Groups      []ActionGroup `json:"groups"`
+	Unmatched   []string      `json:"unmatched,omitempty"`
+}
+
+type PermissionsResponse struct {
+	CatalogVersion string                 `json:"catalogVersion"`
+	CatalogPartial bool                   `json:"catalogPartial"`
+	Statements     []StatementPermissions `json:"statements"`
+	Granted        []ActionGroup          `json:"granted"`
+	GrantedCount   int                    `json:"grantedCount"`
+}
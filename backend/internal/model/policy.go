@@ -1,8 +1,10 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"strconv"
 )
 
 type StringOrSlice []string
@@ -90,7 +92,89 @@ func (p Principal) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.Members)
 }
 
-type Condition map[string]map[string]StringOrSlice
+// ConditionValue keeps condition values in the JSON type they were written in
+// (string, bool or json.Number), and whether they were a bare value or an
+// array, so a policy can be written back exactly as it was read.
+type ConditionValue struct {
+	Values []any
+	Scalar bool
+}
+
+func NewConditionValue(values ...any) ConditionValue {
+	return ConditionValue{Values: values}
+}
+
+func (c *ConditionValue) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	if arr, ok := raw.([]interface{}); ok {
+		values := make([]any, 0, len(arr))
+		for _, item := range arr {
+			if !isConditionScalar(item) {
+				return fmt.Errorf("expected string, boolean or number in condition array, got %T", item)
+			}
+			values = append(values, item)
+		}
+		*c = ConditionValue{Values: values}
+		return nil
+	}
+
+	if !isConditionScalar(raw) {
+		return fmt.Errorf("expected string, boolean, number or array, got %T", raw)
+	}
+	*c = ConditionValue{Values: []any{raw}, Scalar: true}
+	return nil
+}
+
+func (c ConditionValue) MarshalJSON() ([]byte, error) {
+	if c.Scalar && len(c.Values) == 1 {
+		return json.Marshal(c.Values[0])
+	}
+	if c.Values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(c.Values)
+}
+
+// Strings returns the values the way IAM compares them: every condition value
+// is ultimately matched as text.
+func (c ConditionValue) Strings() []string {
+	out := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		out[i] = ConditionValueString(v)
+	}
+	return out
+}
+
+func ConditionValueString(v any) string {
+	switch tv := v.(type) {
+	case string:
+		return tv
+	case bool:
+		return strconv.FormatBool(tv)
+	case json.Number:
+		return tv.String()
+	default:
+		return fmt.Sprint(tv)
+	}
+}
+
+func isConditionScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+type Condition map[string]map[string]ConditionValue
 
 type Statement struct {
 	Sid          string        `json:"Sid,omitempty"`
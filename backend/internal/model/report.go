@@ -9,17 +9,28 @@ const (
 )
 
 type Finding struct {
+	RuleID      string   `json:"ruleId,omitempty"`
 	Severity    Severity `json:"severity"`
 	Title       string   `json:"title"`
 	Explanation string   `json:"explanation"`
 	Evidence    string   `json:"evidence"`
 	StmtIndices []int    `json:"statementIndices"`
+
+	// OriginalIndices are StmtIndices translated to the statement order of
+	// the submitted document.
+	OriginalIndices []int `json:"originalStatementIndices,omitempty"`
+
+	// Locations has one entry per statement in StmtIndices, in the original
+	// document, when the input was parsed from source.
+	Locations []SourceRange `json:"locations,omitempty"`
 }
 
 type ScoreBreakdown struct {
-	Label string `json:"label"`
-	Value string `json:"value"`
-	Score int    `json:"score"`
+	Label           string `json:"label"`
+	Value           string `json:"value"`
+	Score           int    `json:"score"`
+	StmtIndices     []int  `json:"statementIndices,omitempty"`
+	OriginalIndices []int  `json:"originalStatementIndices,omitempty"`
 }
 
 type ScoreResult struct {
@@ -29,17 +40,25 @@ type ScoreResult struct {
 }
 
 type Patch struct {
-	ID          string                `json:"id"`
-	Title       string                `json:"title"`
-	Impact      string                `json:"impact"`
-	DiffPreview string                `json:"diffPreview"`
-	Apply       func(*Policy) *Policy `json:"-"`
+	ID              string                `json:"id"`
+	Title           string                `json:"title"`
+	Impact          string                `json:"impact"`
+	DiffPreview     string                `json:"diffPreview"`
+	StmtIndices     []int                 `json:"statementIndices,omitempty"`
+	OriginalIndices []int                 `json:"originalStatementIndices,omitempty"`
+	Apply           func(*Policy) *Policy `json:"-"`
 }
 
 type GraphNode struct {
-	Index  int    `json:"index"`
-	Label  string `json:"label"`
-	Effect string `json:"effect"`
+	Index         int    `json:"index"`
+	OriginalIndex int    `json:"originalIndex"`
+	Sid           string `json:"sid,omitempty"`
+	Label         string `json:"label"`
+	Effect        string `json:"effect"`
+
+	// ShadowedBy lists, by original index, the Deny statements that override
+	// this whole Allow statement.
+	ShadowedBy []int `json:"shadowedBy,omitempty"`
 }
 
 type GraphEdge struct {
@@ -61,16 +80,102 @@ type AnalyzeResponse struct {
 	Findings    []Finding   `json:"findings"`
 	Suggestions []Patch     `json:"suggestions"`
 	Graph       *GraphData  `json:"graph,omitempty"`
+
+	// Mapping lists, for each statement of Normalized, where it was in
+	// Original.
+	Mapping StatementMapping `json:"mapping"`
+
+	// Warnings are validation issues that did not stop the analysis.
+	Warnings []Issue `json:"warnings,omitempty"`
+}
+
+// TemplatePolicy is a policy document found in a CloudFormation template,
+// with its analysis or the reason it could not be analyzed.
+type TemplatePolicy struct {
+	LogicalID    string           `json:"logicalId"`
+	ResourceType string           `json:"resourceType"`
+	Path         string           `json:"path"`
+	PolicyName   string           `json:"policyName,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	Issues       []Issue          `json:"issues,omitempty"`
+	Analysis     *AnalyzeResponse `json:"analysis,omitempty"`
+}
+
+type TemplateResponse struct {
+	Policies []TemplatePolicy `json:"policies"`
+}
+
+// TerraformPolicy is a policy document found in a Terraform plan or HCL file,
+// keyed by the address of its resource. File and Line are set for HCL.
+type TerraformPolicy struct {
+	Address      string           `json:"address"`
+	ResourceType string           `json:"resourceType"`
+	Attribute    string           `json:"attribute"`
+	PolicyName   string           `json:"policyName,omitempty"`
+	File         string           `json:"file,omitempty"`
+	Line         int              `json:"line,omitempty"`
+	Error        string           `json:"error,omitempty"`
+	Issues       []Issue          `json:"issues,omitempty"`
+	Analysis     *AnalyzeResponse `json:"analysis,omitempty"`
+}
+
+type TerraformResponse struct {
+	Policies []TerraformPolicy `json:"policies"`
+}
+
+// AccountPolicy is one policy document applying to an account entity. Via is
+// the group a user gets it from.
+type AccountPolicy struct {
+	Name     string           `json:"name"`
+	Arn      string           `json:"arn,omitempty"`
+	Kind     string           `json:"kind"`
+	Via      string           `json:"via,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Issues   []Issue          `json:"issues,omitempty"`
+	Analysis *AnalyzeResponse `json:"analysis,omitempty"`
+}
+
+// AccountStatement says which policy a statement of an entity's combined
+// policy was taken from.
+type AccountStatement struct {
+	Policy    int `json:"policy"`
+	Statement int `json:"statement"`
+}
+
+// AccountEntity is a user, group, role or managed policy from an account
+// authorization details dump. Analysis covers the statements of every valid
+// policy in Policies combined, with only the findings that span policies and
+// no suggestions; Statements maps them back.
+type AccountEntity struct {
+	Type       string             `json:"type"`
+	Name       string             `json:"name"`
+	Arn        string             `json:"arn"`
+	Policies   []AccountPolicy    `json:"policies"`
+	Statements []AccountStatement `json:"statements,omitempty"`
+	Missing    []string           `json:"missingPolicies,omitempty"`
+	Analysis   *AnalyzeResponse   `json:"analysis,omitempty"`
+
+	// Trust is the analysis of a role's assume role policy.
+	Trust      *AnalyzeResponse `json:"trust,omitempty"`
+	TrustError string           `json:"trustError,omitempty"`
+}
+
+type AccountResponse struct {
+	Entities []AccountEntity `json:"entities"`
 }
 
 type ApplyRequest struct {
 	Policy   *Policy  `json:"policy"`
 	PatchIDs []string `json:"patchIds"`
+
+	// Format "yaml" also returns the simplified policy as YAML.
+	Format string `json:"format,omitempty"`
 }
 
 type ApplyResponse struct {
-	Simplified *Policy     `json:"simplified"`
-	Score      ScoreResult `json:"score"`
-	Findings   []Finding   `json:"findings"`
-	Graph      *GraphData  `json:"graph,omitempty"`
+	Simplified     *Policy     `json:"simplified"`
+	SimplifiedYAML string      `json:"simplifiedYaml,omitempty"`
+	Score          ScoreResult `json:"score"`
+	Findings       []Finding   `json:"findings"`
+	Graph          *GraphData  `json:"graph,omitempty"`
 }
@@ -0,0 +1,83 @@
+package model
+
+import "encoding/json"
+
+type Decision string
+
+const (
+	DecisionAllow        Decision = "Allow"
+	DecisionExplicitDeny Decision = "ExplicitDeny"
+	DecisionImplicitDeny Decision = "ImplicitDeny"
+)
+
+type RequestPrincipal struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AccessRequest struct {
+	Principal       *RequestPrincipal         `json:"principal,omitempty"`
+	Action          string                    `json:"action"`
+	Resource        string                    `json:"resource"`
+	ResourceAccount string                    `json:"resourceAccount,omitempty"`
+	Context         map[string]ConditionValue `json:"context,omitempty"`
+}
+
+// SimulateRequest keeps the policy as written so it is validated by the
+// parser like any other document.
+type SimulateRequest struct {
+	Policy  json.RawMessage `json:"policy"`
+	Request AccessRequest   `json:"request"`
+}
+
+type SimulateResponse struct {
+	Decision          Decision `json:"decision"`
+	MatchedStatements []int    `json:"matchedStatements"`
+}
+
+type PolicyType string
+
+const (
+	PolicyTypeIdentity           PolicyType = "identity"
+	PolicyTypeResource           PolicyType = "resource"
+	PolicyTypeSCP                PolicyType = "scp"
+	PolicyTypePermissionBoundary PolicyType = "permissionBoundary"
+	PolicyTypeSession            PolicyType = "session"
+)
+
+type TypedPolicy struct {
+	Type   PolicyType `json:"type"`
+	Name   string     `json:"name,omitempty"`
+	Policy *Policy    `json:"policy"`
+}
+
+type PolicySet struct {
+	Policies []TypedPolicy `json:"policies"`
+}
+
+// RawTypedPolicy is a TypedPolicy whose document has not been parsed yet.
+type RawTypedPolicy struct {
+	Type   PolicyType      `json:"type"`
+	Name   string          `json:"name,omitempty"`
+	Policy json.RawMessage `json:"policy"`
+}
+
+type EvaluateRequest struct {
+	Policies []RawTypedPolicy `json:"policies"`
+	Request  AccessRequest    `json:"request"`
+}
+
+type PolicyResult struct {
+	Index             int        `json:"index"`
+	Type              PolicyType `json:"type"`
+	Name              string     `json:"name,omitempty"`
+	Decision          Decision   `json:"decision"`
+	MatchedStatements []int      `json:"matchedStatements"`
+}
+
+type EvaluateResponse struct {
+	Decision Decision       `json:"decision"`
+	DeniedBy PolicyType     `json:"deniedBy,omitempty"`
+	Reason   string         `json:"reason"`
+	Policies []PolicyResult `json:"policies"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// Position is a location in a policy document. Line and Column are 1-based
+// and columns count Unicode code points.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// SourceRange points at a statement of the original document, or at one of
+// its fields.
+type SourceRange struct {
+	Statement int      `json:"statement"`
+	Start     Position `json:"start"`
+	End       Position `json:"end"`
+}
@@ -0,0 +1,20 @@
+package model
+
+type IssueLevel string
+
+const (
+	IssueError   IssueLevel = "error"
+	IssueWarning IssueLevel = "warning"
+)
+
+// Issue is one problem found while validating a policy document. Errors make
+// the policy unusable; warnings are worth fixing but do not stop analysis.
+// Statement is -1 for problems with the document itself.
+type Issue struct {
+	Level     IssueLevel `json:"level"`
+	Code      string     `json:"code"`
+	Message   string     `json:"message"`
+	Statement int        `json:"statement"`
+	Field     string     `json:"field,omitempty"`
+	Position  *Position  `json:"position,omitempty"`
+}
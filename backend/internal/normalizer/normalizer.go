@@ -8,20 +8,33 @@ import (
 )
 
 func Normalize(p *model.Policy) *model.Policy {
+	normalized, _ := NormalizeWithMapping(p)
+	return normalized
+}
+
+// NormalizeWithMapping also returns, for each normalized statement, the
+// statement of p it was produced from.
+func NormalizeWithMapping(p *model.Policy) (*model.Policy, model.StatementMapping) {
 	normalized := &model.Policy{
 		Version: p.Version,
 		Id:      p.Id,
 	}
 
 	stmts := make([]model.Statement, len(p.Statement))
+	order := make([]int, len(p.Statement))
 	for i, s := range p.Statement {
 		stmts[i] = deepCopyStatement(s)
+		order[i] = i
 	}
 
-	sortStatements(stmts)
+	sortStatements(stmts, order)
 	normalized.Statement = stmts
 
-	return normalized
+	mapping := make(model.StatementMapping, len(stmts))
+	for i, orig := range order {
+		mapping[i] = model.StatementRef{Normalized: i, Original: orig, Sid: stmts[i].Sid}
+	}
+	return normalized, mapping
 }
 
 func deepCopyStatement(s model.Statement) model.Statement {
@@ -70,9 +83,9 @@ func deepCopyCondition(c model.Condition) model.Condition {
 
 	cp := make(model.Condition, len(c))
 	for op, kvs := range c {
-		cpKvs := make(map[string]model.StringOrSlice, len(kvs))
+		cpKvs := make(map[string]model.ConditionValue, len(kvs))
 		for k, v := range kvs {
-			cpKvs[k] = dedupSorted(v)
+			cpKvs[k] = canonicalConditionValue(v)
 		}
 		cp[op] = cpKvs
 	}
@@ -80,6 +93,30 @@ func deepCopyCondition(c model.Condition) model.Condition {
 	return cp
 }
 
+// canonicalConditionValue sorts and dedups values by their textual form, which
+// is how IAM compares them, so "true" and true collapse into one entry. The
+// first occurrence keeps its JSON type, and the result is always an array.
+func canonicalConditionValue(v model.ConditionValue) model.ConditionValue {
+	if v.Values == nil {
+		return model.ConditionValue{}
+	}
+
+	values := make([]any, len(v.Values))
+	copy(values, v.Values)
+	sort.SliceStable(values, func(i, j int) bool {
+		return model.ConditionValueString(values[i]) < model.ConditionValueString(values[j])
+	})
+
+	result := make([]any, 0, len(values))
+	for i, val := range values {
+		if i == 0 || model.ConditionValueString(val) != model.ConditionValueString(values[i-1]) {
+			result = append(result, val)
+		}
+	}
+
+	return model.ConditionValue{Values: result}
+}
+
 func dedupSorted(ss []string) []string {
 	if ss == nil {
 		return nil
@@ -99,16 +136,31 @@ func dedupSorted(ss []string) []string {
 	return result
 }
 
-func sortStatements(stmts []model.Statement) {
-	sort.SliceStable(stmts, func(i, j int) bool {
-		if stmts[i].Effect != stmts[j].Effect {
-			return stmts[i].Effect < stmts[j].Effect
-		}
+func sortStatements(stmts []model.Statement, order []int) {
+	sort.Stable(statementSorter{stmts, order})
+}
 
-		aFirst := firstAction(stmts[i])
-		bFirst := firstAction(stmts[j])
-		return aFirst < bFirst
-	})
+// statementSorter keeps the original indices in step with the statements.
+type statementSorter struct {
+	stmts []model.Statement
+	order []int
+}
+
+func (s statementSorter) Len() int { return len(s.stmts) }
+
+func (s statementSorter) Swap(i, j int) {
+	s.stmts[i], s.stmts[j] = s.stmts[j], s.stmts[i]
+	s.order[i], s.order[j] = s.order[j], s.order[i]
+}
+
+func (s statementSorter) Less(i, j int) bool {
+	if s.stmts[i].Effect != s.stmts[j].Effect {
+		return s.stmts[i].Effect < s.stmts[j].Effect
+	}
+
+	aFirst := firstAction(s.stmts[i])
+	bFirst := firstAction(s.stmts[j])
+	return aFirst < bFirst
 }
 
 func firstAction(s model.Statement) string {
@@ -52,6 +52,31 @@ func TestNormalize_SortsStatements(t *testing.T) {
 	}
 }
 
+func TestNormalizeWithMapping(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:DeleteObject"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:PutObject"}, Resource: model.StringOrSlice{"*"}},
+			{Sid: "Read", Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	n, mapping := normalizer.NormalizeWithMapping(p)
+	want := []int{2, 1, 0}
+	for i := range want {
+		if mapping[i].Normalized != i || mapping[i].Original != want[i] {
+			t.Fatalf("expected originals %v, got %+v", want, mapping)
+		}
+		if n.Statement[i].Action[0] != p.Statement[mapping[i].Original].Action[0] {
+			t.Errorf("normalized statement %d does not come from original %d", i, mapping[i].Original)
+		}
+	}
+	if mapping[0].Sid != "Read" || mapping.Ref(0) != "2 (Read)" || mapping.Ref(1) != "1" {
+		t.Errorf("unexpected refs %q, %q", mapping.Ref(0), mapping.Ref(1))
+	}
+}
+
 func TestNormalize_DoesNotMutateOriginal(t *testing.T) {
 	p := &model.Policy{
 		Version: "2012-10-17",
@@ -75,3 +100,36 @@ func TestNormalize_DoesNotMutateOriginal(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalize_CanonicalisesConditionValues(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{
+				Effect:   "Deny",
+				Action:   model.StringOrSlice{"s3:*"},
+				Resource: model.StringOrSlice{"*"},
+				Condition: model.Condition{
+					"Bool":         {"aws:SecureTransport": model.ConditionValue{Values: []any{false}, Scalar: true}},
+					"StringEquals": {"aws:PrincipalTag/team": model.NewConditionValue("web", "data", "web")},
+				},
+			},
+		},
+	}
+
+	n := normalizer.Normalize(p)
+
+	secure := n.Statement[0].Condition["Bool"]["aws:SecureTransport"]
+	if secure.Scalar || len(secure.Values) != 1 || secure.Values[0] != false {
+		t.Errorf("expected canonical [false], got %+v", secure)
+	}
+
+	teams := n.Statement[0].Condition["StringEquals"]["aws:PrincipalTag/team"].Strings()
+	if len(teams) != 2 || teams[0] != "data" || teams[1] != "web" {
+		t.Errorf("expected [data web], got %v", teams)
+	}
+
+	if !p.Statement[0].Condition["Bool"]["aws:SecureTransport"].Scalar {
+		t.Error("normalize must not modify the original condition")
+	}
+}
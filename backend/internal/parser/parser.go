@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
@@ -11,48 +13,152 @@ import (
 const MaxInputBytes = 1 << 20 // 1 MB
 
 var (
-	ErrInputTooLarge    = errors.New("input exceeds 1MB limit")
-	ErrInvalidJSON      = errors.New("invalid JSON")
-	ErrMissingVersion   = errors.New("missing Version field")
-	ErrMissingStatement = errors.New("missing or empty Statement array")
-	ErrInvalidEffect    = errors.New("statement Effect must be Allow or Deny")
+	ErrInputTooLarge      = errors.New("input exceeds 1MB limit")
+	ErrEmptyInput         = errors.New("empty policy document")
+	ErrInvalidJSON        = errors.New("invalid JSON")
+	ErrNotObject          = errors.New("policy document must be a JSON object")
+	ErrMissingVersion     = errors.New("missing Version field")
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	ErrMissingStatement   = errors.New("missing or empty Statement array")
+	ErrInvalidEffect      = errors.New("statement Effect must be Allow or Deny")
+	ErrMissingAction      = errors.New("must have Action or NotAction")
+	ErrMissingResource    = errors.New("must have Resource, NotResource or Principal")
 )
 
-func Parse(raw []byte) (*model.Policy, error) {
-	if len(raw) > MaxInputBytes {
-		return nil, ErrInputTooLarge
-	}
+// ParseError is a parse or validation error together with where in the input
+// it was found. Statement is -1 for errors outside the Statement array, and
+// Position is zero when no location is known.
+type ParseError struct {
+	Err       error
+	Statement int
+	Field     string
+	Position  model.Position
+}
 
-	var policy model.Policy
-	if err := json.Unmarshal(raw, &policy); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+func (e *ParseError) Error() string {
+	if e.Position.Line == 0 {
+		return e.Err.Error()
 	}
+	return fmt.Sprintf("line %d, column %d: %v", e.Position.Line, e.Position.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func Parse(raw []byte, opts ...Option) (*model.Policy, error) {
+	policy, _, err := ParseSource(raw, opts...)
+	return policy, err
+}
 
-	if policy.Version == "" {
-		return nil, ErrMissingVersion
+// ParseSource is Parse that also returns the location of every statement and
+// field in raw. It stops at the first error; Validate reports all of them.
+func ParseSource(raw []byte, opts ...Option) (*model.Policy, *SourceMap, error) {
+	v, err := Validate(raw, opts...)
+	if err != nil {
+		return nil, nil, err
 	}
+	if len(v.errs) > 0 {
+		return nil, nil, v.errs[0]
+	}
+	return v.Policy, v.Source, nil
+}
+
+// errorAt positions err at a field of statement stmt (or of the document when
+// stmt is -1), falling back to the statement and then the document start.
+func (m *SourceMap) errorAt(stmt int, field string, err error) *ParseError {
+	pe := &ParseError{Err: err, Statement: stmt, Field: field}
 
-	// Current version of policy language and legacy one
-	if policy.Version != "2012-10-17" && policy.Version != "2008-10-17" {
-		return nil, fmt.Errorf("unsupported version: %q", policy.Version)
+	span, ok := Span{}, false
+	if stmt < 0 {
+		span, ok = lookupField(m.Fields, field)
+	} else if stmt < len(m.Statements) {
+		span, ok = m.Field(stmt, field)
+		if !ok {
+			pe.Field = ""
+			span, ok = m.Statements[stmt].Span, true
+		}
+	}
+	if !ok {
+		pe.Field = ""
 	}
+	pe.Position = m.Position(span.Start)
+	return pe
+}
 
-	if len(policy.Statement) == 0 {
-		return nil, ErrMissingStatement
+func syntaxError(raw []byte, syn *json.SyntaxError) *ParseError {
+	// Offset counts the offending byte; point at it rather than past it.
+	offset := int(syn.Offset) - 1
+	if offset < 0 {
+		offset = 0
 	}
+	m := &SourceMap{input: raw, lineStarts: lineStarts(raw)}
+	return &ParseError{Err: fmt.Errorf("%w: %v", ErrInvalidJSON, syn), Statement: -1, Position: m.Position(offset)}
+}
 
-	for i, stmt := range policy.Statement {
-		if stmt.Effect != "Allow" && stmt.Effect != "Deny" {
-			return nil, fmt.Errorf("%w: statement %d has Effect %q", ErrInvalidEffect, i, stmt.Effect)
+// decodeErrors locates a json.Unmarshal failure that is not a syntax error.
+// Those come from a field's UnmarshalJSON, so the document is decoded again
+// piece by piece to find every offending statement and field.
+func (m *SourceMap) decodeErrors(err error) []*ParseError {
+	raw := m.input
+	var errs []*ParseError
+	for i, st := range m.Statements {
+		found := false
+		for _, field := range fieldsInOrder(st.Fields) {
+			span := st.Fields[field]
+			if fieldErr := json.Unmarshal(wrapField(field, raw[span.Start:span.End]), &model.Statement{}); fieldErr != nil {
+				errs = append(errs, &ParseError{Err: fmt.Errorf("%w: statement %d %s: %v", ErrInvalidJSON, i, field, fieldErr), Statement: i, Field: field, Position: m.Position(span.Start)})
+				found = true
+			}
 		}
-
-		if len(stmt.Action) == 0 && len(stmt.NotAction) == 0 {
-			return nil, fmt.Errorf("statement %d must have Action or NotAction", i)
+		if !found {
+			if stmtErr := json.Unmarshal(raw[st.Start:st.End], &model.Statement{}); stmtErr != nil {
+				errs = append(errs, &ParseError{Err: fmt.Errorf("%w: statement %d: %v", ErrInvalidJSON, i, stmtErr), Statement: i, Position: m.Position(st.Start)})
+			}
+		}
+	}
+	for _, field := range fieldsInOrder(m.Fields) {
+		if len(errs) > 0 && strings.EqualFold(field, "Statement") {
+			continue
 		}
+		span := m.Fields[field]
+		if fieldErr := json.Unmarshal(wrapField(field, raw[span.Start:span.End]), &model.Policy{}); fieldErr != nil {
+			errs = append(errs, &ParseError{Err: fmt.Errorf("%w: %s: %v", ErrInvalidJSON, field, fieldErr), Statement: -1, Field: field, Position: m.Position(span.Start)})
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, &ParseError{Err: fmt.Errorf("%w: %v", ErrInvalidJSON, err), Statement: -1, Position: m.Position(0)})
+	}
+	return errs
+}
+
+func fieldsInOrder(fields map[string]Span) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return fields[names[i]].Start < fields[names[j]].Start
+	})
+	return names
+}
+
+func wrapField(name string, value []byte) []byte {
+	key, _ := json.Marshal(name)
+	out := make([]byte, 0, len(key)+len(value)+3)
+	out = append(out, '{')
+	out = append(out, key...)
+	out = append(out, ':')
+	out = append(out, value...)
+	return append(out, '}')
+}
 
-		if len(stmt.Resource) == 0 && len(stmt.NotResource) == 0 && stmt.Principal == nil {
-			return nil, fmt.Errorf("statement %d must have Resource, NotResource or Principal", i)
+func lineStarts(raw []byte) []int {
+	starts := []int{0}
+	for i, b := range raw {
+		if b == '\n' {
+			starts = append(starts, i+1)
 		}
 	}
-	return &policy, nil
+	return starts
 }
@@ -1,6 +1,9 @@
 package parser_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -49,6 +52,23 @@ func TestParse_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestParse_Empty(t *testing.T) {
+	for _, raw := range []string{"", "  \n\t"} {
+		if _, err := parser.Parse([]byte(raw)); !errors.Is(err, parser.ErrEmptyInput) {
+			t.Errorf("%q: expected ErrEmptyInput, got %v", raw, err)
+		}
+	}
+}
+
+func TestParse_NotObject(t *testing.T) {
+	for _, raw := range []string{`[]`, `[1]`, ` "policy"`, `null`, "- a\n- b\n"} {
+		_, err := parser.Parse([]byte(raw))
+		if !errors.Is(err, parser.ErrNotObject) {
+			t.Errorf("%q: expected ErrNotObject, got %v", raw, err)
+		}
+	}
+}
+
 func TestParse_MissingVersion(t *testing.T) {
 	raw := []byte(`{"Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`)
 	_, err := parser.Parse(raw)
@@ -83,3 +103,145 @@ func TestParse_SingletonAction(t *testing.T) {
 		t.Errorf("expected singleton action wrapped in slice, got %v", p.Statement[0].Action)
 	}
 }
+
+func TestParse_TypedConditionValues(t *testing.T) {
+	raw := []byte(`{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Deny",
+			"Action": "s3:*",
+			"Resource": "*",
+			"Condition": {
+				"Bool": {"aws:SecureTransport": false},
+				"NumericLessThan": {"s3:max-keys": 10},
+				"StringEquals": {"aws:PrincipalTag/team": ["data", 7, true]}
+			}
+		}]
+	}`)
+
+	p, err := parser.Parse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cond := p.Statement[0].Condition
+	if got := cond["Bool"]["aws:SecureTransport"].Strings(); len(got) != 1 || got[0] != "false" {
+		t.Errorf("expected Bool value [false], got %v", got)
+	}
+	if got := cond["NumericLessThan"]["s3:max-keys"].Strings(); len(got) != 1 || got[0] != "10" {
+		t.Errorf("expected NumericLessThan value [10], got %v", got)
+	}
+
+	out, err := json.Marshal(p.Statement[0].Condition)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"Bool":{"aws:SecureTransport":false},"NumericLessThan":{"s3:max-keys":10},"StringEquals":{"aws:PrincipalTag/team":["data",7,true]}}`
+	if string(out) != want {
+		t.Errorf("condition did not round-trip:\n got %s\nwant %s", out, want)
+	}
+}
+
+func TestParse_InvalidConditionValue(t *testing.T) {
+	raw := []byte(`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*", "Condition": {"StringEquals": {"aws:username": {"nested": "object"}}}}]}`)
+	_, err := parser.Parse(raw)
+	if err == nil {
+		t.Fatal("expected error for object condition value")
+	}
+}
+
+func TestValidate_CollectsAllIssues(t *testing.T) {
+	raw := []byte(`{"Version": "2012-10-17", "Statement": [
+		{"Effect": "Maybe", "Action": "s3:GetObject", "Resource": "*"},
+		{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject"},
+		{"Sid": "Read", "Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}
+	]}`)
+
+	v, err := parser.Validate(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Policy != nil {
+		t.Error("expected no policy when validation fails")
+	}
+
+	var got []string
+	for _, is := range v.Issues {
+		got = append(got, fmt.Sprintf("%s:%s:%d", is.Level, is.Code, is.Statement))
+	}
+	want := []string{
+		"error:invalid-effect:0",
+		"warning:missing-sid:0",
+		"error:missing-resource:1",
+		"warning:duplicate-sid:2",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expected issues %v, got %v", want, got)
+	}
+
+	verr := v.Err()
+	if !errors.Is(verr, parser.ErrInvalidEffect) || !errors.Is(verr, parser.ErrMissingResource) {
+		t.Errorf("expected both sentinels to match, got %v", verr)
+	}
+	if !strings.HasSuffix(verr.Error(), "(and 1 more)") {
+		t.Errorf("unexpected message %q", verr.Error())
+	}
+}
+
+func TestValidate_WarningsOnly(t *testing.T) {
+	raw := []byte(`{"Version": "2008-10-17", "Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`)
+
+	v, err := parser.Validate(raw)
+	if err != nil || v.Err() != nil {
+		t.Fatalf("unexpected error: %v %v", err, v.Err())
+	}
+	if v.Policy == nil {
+		t.Fatal("expected a policy")
+	}
+	if w := v.Warnings(); len(w) != 1 || w[0].Code != "legacy-version" {
+		t.Errorf("expected a legacy version warning, got %+v", w)
+	}
+	if _, err := parser.Parse(raw); err != nil {
+		t.Errorf("warnings should not fail Parse: %v", err)
+	}
+}
+
+func TestParse_Strict(t *testing.T) {
+	raw := []byte(`{"Version": "2012-10-17", "Statement": [{
+		"Sid": "Read",
+		"effect": "Allow",
+		"Action": "s3:GetObject",
+		"Resource": "*",
+		"Resources": "arn:aws:s3:::b/*",
+		"Action": "s3:PutObject",
+		"Condition": {"StringEquals": {"aws:PrincipalAccount": "111122223333", "aws:PrincipalAccount": "444455556666"}}
+	}]}`)
+
+	if _, err := parser.Parse(raw); err != nil {
+		t.Fatalf("unexpected error without strict mode: %v", err)
+	}
+
+	v, err := parser.Validate(raw, parser.WithStrict())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, is := range v.Issues {
+		got = append(got, is.Code+":"+is.Field)
+	}
+	want := []string{"key-case:effect", "unknown-key:Resources", "duplicate-key:Action", "duplicate-key:Condition.StringEquals"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected issues %v, got %v", want, got)
+	}
+	if !strings.Contains(v.Issues[1].Message, `did you mean "Resource"?`) {
+		t.Errorf("expected a suggestion, got %q", v.Issues[1].Message)
+	}
+	if v.Issues[1].Position == nil || v.Issues[1].Position.Line != 6 {
+		t.Errorf("expected the key position on line 6, got %+v", v.Issues[1].Position)
+	}
+
+	_, err = parser.Parse(raw, parser.WithStrict())
+	if !errors.Is(err, parser.ErrKeyCase) {
+		t.Errorf("expected ErrKeyCase, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Span is a half-open byte range [Start, End) in the parsed input.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// StatementSource is where one statement and each of its fields came from.
+// Field spans cover the value, keyed by the name as written in the input.
+type StatementSource struct {
+	Span
+	Fields map[string]Span
+
+	keys   []key
+	nested []nestedKeys
+}
+
+// nestedKeys are the keys of an object inside a statement, such as a
+// Condition block, found at a dotted path like "Condition.StringEquals".
+type nestedKeys struct {
+	path string
+	keys []key
+}
+
+// key is an object key as written, in input order, duplicates included.
+type key struct {
+	name string
+	at   int
+}
+
+// SourceMap is a side table of input locations for a parsed policy, indexed
+// like Policy.Statement.
+type SourceMap struct {
+	Fields     map[string]Span
+	Statements []StatementSource
+
+	keys       []key
+	input      []byte
+	lineStarts []int
+
+	// anchors is set when input was converted from YAML; positions then
+	// refer to the YAML document.
+	anchors []anchor
+}
+
+// newSourceMap indexes a syntactically valid document. Parts of the document
+// that do not have the expected shape are left out rather than reported.
+func newSourceMap(raw []byte) *SourceMap {
+	m := &SourceMap{input: raw, lineStarts: lineStarts(raw)}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	m.Fields, m.keys, _ = objectFields(dec, raw, func(key string) bool {
+		if !strings.EqualFold(key, "Statement") {
+			return false
+		}
+		m.Statements = statementSources(dec, raw)
+		return true
+	})
+	return m
+}
+
+// objectFields reads an object from dec and returns the value span of each
+// key, along with the keys in input order. visit may consume a value itself by
+// returning true; its span is still recorded.
+func objectFields(dec *json.Decoder, raw []byte, visit func(name string) bool) (map[string]Span, []key, bool) {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, false
+	}
+
+	fields := make(map[string]Span)
+	var keys []key
+	for dec.More() {
+		at := skipSeparators(raw, int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			return fields, keys, false
+		}
+		name, _ := tok.(string)
+		keys = append(keys, key{name: name, at: at})
+		start := skipSeparators(raw, int(dec.InputOffset()))
+
+		if visit == nil || !visit(name) {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fields, keys, false
+			}
+		}
+		fields[name] = Span{Start: start, End: int(dec.InputOffset())}
+	}
+	_, err := dec.Token()
+	return fields, keys, err == nil
+}
+
+func statementSources(dec *json.Decoder, raw []byte) []StatementSource {
+	if !dec.More() {
+		return nil
+	}
+	start := skipSeparators(raw, int(dec.InputOffset()))
+	if raw[start] != '[' {
+		var skip json.RawMessage
+		dec.Decode(&skip)
+		return nil
+	}
+	dec.Token()
+
+	var stmts []StatementSource
+	for dec.More() {
+		start := skipSeparators(raw, int(dec.InputOffset()))
+		var fields map[string]Span
+		var keys []key
+		var nested []nestedKeys
+		if raw[start] == '{' {
+			fields, keys, _ = objectFields(dec, raw, func(name string) bool {
+				return nestedObjects(dec, raw, name, &nested)
+			})
+		} else {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return stmts
+			}
+		}
+		stmts = append(stmts, StatementSource{
+			Span:   Span{Start: start, End: int(dec.InputOffset())},
+			Fields: fields,
+			keys:   keys,
+			nested: nested,
+		})
+	}
+	dec.Token()
+	return stmts
+}
+
+// nestedObjects records the keys of the object value at path and of every
+// object nested in it, parents first. It reports false without consuming
+// anything when the value is not an object.
+func nestedObjects(dec *json.Decoder, raw []byte, path string, out *[]nestedKeys) bool {
+	if i := skipSeparators(raw, int(dec.InputOffset())); i >= len(raw) || raw[i] != '{' {
+		return false
+	}
+	n := len(*out)
+	*out = append(*out, nestedKeys{path: path})
+	_, keys, _ := objectFields(dec, raw, func(name string) bool {
+		return nestedObjects(dec, raw, path+"."+name, out)
+	})
+	(*out)[n].keys = keys
+	return true
+}
+
+func skipSeparators(raw []byte, i int) int {
+	for i < len(raw) {
+		switch raw[i] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// Position converts a byte offset into a line and column.
+func (m *SourceMap) Position(offset int) model.Position {
+	if m.anchors != nil {
+		return m.anchorPosition(offset)
+	}
+	if offset > len(m.input) {
+		offset = len(m.input)
+	}
+	line := 0
+	for line+1 < len(m.lineStarts) && m.lineStarts[line+1] <= offset {
+		line++
+	}
+	start := m.lineStarts[line]
+	return model.Position{Offset: offset, Line: line + 1, Column: utf8.RuneCount(m.input[start:offset]) + 1}
+}
+
+// Statement returns the start and end positions of statement i.
+func (m *SourceMap) Statement(i int) (model.Position, model.Position, bool) {
+	if m == nil || i < 0 || i >= len(m.Statements) {
+		return model.Position{}, model.Position{}, false
+	}
+	s := m.Statements[i]
+	return m.Position(s.Start), m.Position(s.End), true
+}
+
+// Field returns the span of a field's value in statement i, matching the name
+// case-insensitively like encoding/json does.
+func (m *SourceMap) Field(i int, name string) (Span, bool) {
+	if m == nil || i < 0 || i >= len(m.Statements) {
+		return Span{}, false
+	}
+	return lookupField(m.Statements[i].Fields, name)
+}
+
+func lookupField(fields map[string]Span, name string) (Span, bool) {
+	if s, ok := fields[name]; ok {
+		return s, true
+	}
+	for k, s := range fields {
+		if strings.EqualFold(k, name) {
+			return s, true
+		}
+	}
+	return Span{}, false
+}
+
+// Range returns the location of statement i, narrowed to one of its fields
+// when field is set and present.
+func (m *SourceMap) Range(i int, field string) (model.SourceRange, bool) {
+	if m == nil || i < 0 || i >= len(m.Statements) {
+		return model.SourceRange{}, false
+	}
+	span := m.Statements[i].Span
+	if field != "" {
+		if s, ok := m.Field(i, field); ok {
+			span = s
+		}
+	}
+	return model.SourceRange{Statement: i, Start: m.Position(span.Start), End: m.Position(span.End)}, true
+}
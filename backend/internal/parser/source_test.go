@@ -0,0 +1,134 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+)
+
+const sourcePolicy = "{\n" +
+	"  \"Version\": \"2012-10-17\",\n" +
+	"  \"Statement\": [\n" +
+	"    {\"Effect\": \"Allow\", \"Action\": \"s3:GetObject\", \"Resource\": \"*\"},\n" +
+	"    {\n" +
+	"      \"Sid\": \"Zażółć\", \"Effect\": \"Deny\",\n" +
+	"      \"Action\": \"s3:DeleteObject\", \"Resource\": \"*\"\n" +
+	"    }\n" +
+	"  ]\n" +
+	"}\n"
+
+type lineCol struct{ line, col int }
+
+func TestParseSource_StatementSpans(t *testing.T) {
+	_, src, err := parser.ParseSource([]byte(sourcePolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(src.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(src.Statements))
+	}
+
+	tests := []struct {
+		stmt       int
+		start, end lineCol
+	}{
+		{0, lineCol{4, 5}, lineCol{4, 67}},
+		{1, lineCol{5, 5}, lineCol{8, 6}},
+	}
+	for _, tt := range tests {
+		start, end, ok := src.Statement(tt.stmt)
+		if !ok {
+			t.Fatalf("statement %d: no position", tt.stmt)
+		}
+		if (lineCol{start.Line, start.Column}) != tt.start || (lineCol{end.Line, end.Column}) != tt.end {
+			t.Errorf("statement %d: got %v-%v, want %v-%v", tt.stmt, start, end, tt.start, tt.end)
+		}
+	}
+
+	if _, _, ok := src.Statement(2); ok {
+		t.Error("expected no position for a missing statement")
+	}
+}
+
+func TestParseSource_FieldPositions(t *testing.T) {
+	_, src, err := parser.ParseSource([]byte(sourcePolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Columns count code points, so the multi-byte Sid does not shift Effect.
+	r, ok := src.Range(1, "effect")
+	if !ok || r.Statement != 1 || r.Start.Line != 6 || r.Start.Column != 34 || r.End.Column != 40 {
+		t.Errorf("unexpected Effect range %+v", r)
+	}
+
+	r, _ = src.Range(0, "Missing")
+	if r.Start.Line != 4 || r.Start.Column != 5 {
+		t.Errorf("expected fallback to the statement, got %+v", r)
+	}
+
+	if span, ok := src.Fields["Version"]; !ok || sourcePolicy[span.Start:span.End] != `"2012-10-17"` {
+		t.Errorf("unexpected Version span %+v", span)
+	}
+}
+
+func TestParse_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+		stmt  int
+		field string
+		pos   lineCol
+	}{
+		{
+			name:  "syntax",
+			input: "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [}\n}",
+			want:  parser.ErrInvalidJSON,
+			stmt:  -1,
+			pos:   lineCol{3, 17},
+		},
+		{
+			name:  "field type",
+			input: "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [\n    {\"Effect\": \"Allow\",\n     \"Action\": 42, \"Resource\": \"*\"}\n  ]\n}",
+			want:  parser.ErrInvalidJSON,
+			stmt:  0,
+			field: "Action",
+			pos:   lineCol{5, 16},
+		},
+		{
+			name:  "effect",
+			input: "{\"Version\": \"2012-10-17\", \"Statement\": [\n  {\"Effect\": \"Allow\", \"Action\": \"s3:*\", \"Resource\": \"*\"},\n  {\"Effect\": \"Permit\", \"Action\": \"s3:*\", \"Resource\": \"*\"}\n]}",
+			want:  parser.ErrInvalidEffect,
+			stmt:  1,
+			field: "Effect",
+			pos:   lineCol{3, 14},
+		},
+		{
+			name:  "version",
+			input: "{\n  \"Version\": \"2020-01-01\",\n  \"Statement\": []\n}",
+			want:  parser.ErrUnsupportedVersion,
+			stmt:  -1,
+			field: "Version",
+			pos:   lineCol{2, 14},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := parser.Parse([]byte(tt.input))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+			continue
+		}
+		var pe *parser.ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: expected a ParseError, got %T", tt.name, err)
+			continue
+		}
+		if pe.Statement != tt.stmt || pe.Field != tt.field || (lineCol{pe.Position.Line, pe.Position.Column}) != tt.pos {
+			t.Errorf("%s: got statement %d field %q at %d:%d, want %d %q at %v (%v)",
+				tt.name, pe.Statement, pe.Field, pe.Position.Line, pe.Position.Column, tt.stmt, tt.field, tt.pos, err)
+		}
+	}
+}
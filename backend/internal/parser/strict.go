@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/textdist"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown key")
+	ErrKeyCase      = errors.New("key has the wrong case")
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+var (
+	policyKeys    = []string{"Version", "Id", "Statement"}
+	statementKeys = []string{"Sid", "Effect", "Principal", "NotPrincipal", "Action", "NotAction", "Resource", "NotResource", "Condition"}
+)
+
+type options struct {
+	strict bool
+	format Format
+}
+
+type Option func(*options)
+
+// WithStrict rejects keys of the document and its statements that
+// encoding/json would otherwise drop or accept in the wrong case, and keys
+// that appear twice in the same object.
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+func (v *Validation) checkKeys(stmt int, keys []key, known []string) {
+	where := "document"
+	if stmt >= 0 {
+		where = fmt.Sprintf("statement %d", stmt)
+	}
+
+	seen := make(map[string]string)
+	for _, k := range keys {
+		pe := &ParseError{Statement: stmt, Field: k.name, Position: v.Source.Position(k.at)}
+		canonical, ok := knownKey(k.name, known)
+		lower := strings.ToLower(k.name)
+		first, dup := seen[lower]
+
+		switch {
+		case dup:
+			pe.Err = fmt.Errorf("%w: %s has %q more than once", ErrDuplicateKey, where, first)
+		case ok && canonical != k.name:
+			pe.Err = fmt.Errorf("%w: %s key %q should be %q", ErrKeyCase, where, k.name, canonical)
+		case !ok:
+			pe.Err = fmt.Errorf("%w: %s key %q", ErrUnknownKey, where, k.name)
+			if suggestion, found := suggestKey(k.name, known); found {
+				pe.Err = fmt.Errorf("%w; did you mean %q?", pe.Err, suggestion)
+			}
+		}
+		if !dup {
+			seen[lower] = k.name
+		}
+		if pe.Err != nil {
+			v.add(model.IssueError, pe)
+		}
+	}
+}
+
+// checkNestedKeys reports keys that appear twice in an object nested in
+// statement stmt, such as an operator in a Condition block. encoding/json
+// would keep only the last of them.
+func (v *Validation) checkNestedKeys(stmt int, objects []nestedKeys) {
+	for _, obj := range objects {
+		seen := make(map[string]bool)
+		for _, k := range obj.keys {
+			if seen[k.name] {
+				v.add(model.IssueError, &ParseError{
+					Statement: stmt,
+					Field:     obj.path,
+					Position:  v.Source.Position(k.at),
+					Err:       fmt.Errorf("%w: statement %d %s has %q more than once", ErrDuplicateKey, stmt, obj.path, k.name),
+				})
+			}
+			seen[k.name] = true
+		}
+	}
+}
+
+func knownKey(name string, known []string) (string, bool) {
+	for _, k := range known {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// suggestKey finds the intended key for a misspelling such as "Resources" or
+// "Efect".
+func suggestKey(name string, known []string) (string, bool) {
+	lower := strings.ToLower(name)
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := textdist.Distance(lower, strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best, best != "" && bestDist < len(best)
+}
@@ -0,0 +1,252 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Warnings. They are reported by Validate but do not make Parse fail.
+var (
+	ErrLegacyVersion = errors.New("legacy policy version")
+	ErrMissingSid    = errors.New("statement has no Sid")
+	ErrDuplicateSid  = errors.New("duplicate Sid")
+)
+
+var issueCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidJSON, "invalid-json"},
+	{ErrMissingVersion, "missing-version"},
+	{ErrUnsupportedVersion, "unsupported-version"},
+	{ErrMissingStatement, "missing-statement"},
+	{ErrInvalidEffect, "invalid-effect"},
+	{ErrMissingAction, "missing-action"},
+	{ErrMissingResource, "missing-resource"},
+	{ErrLegacyVersion, "legacy-version"},
+	{ErrMissingSid, "missing-sid"},
+	{ErrDuplicateSid, "duplicate-sid"},
+	{ErrUnknownKey, "unknown-key"},
+	{ErrKeyCase, "key-case"},
+	{ErrDuplicateKey, "duplicate-key"},
+}
+
+func issueCode(err error) string {
+	for _, c := range issueCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "invalid"
+}
+
+// Validation is the outcome of checking a whole document. Policy is nil when
+// any issue is an error.
+type Validation struct {
+	Policy *model.Policy
+	Source *SourceMap
+	Issues []model.Issue
+
+	errs []*ParseError
+}
+
+// Err returns a *ValidationError when the document has errors, nil otherwise.
+func (v *Validation) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: v.Issues, errs: v.errs}
+}
+
+func (v *Validation) Warnings() []model.Issue {
+	var warnings []model.Issue
+	for _, is := range v.Issues {
+		if is.Level == model.IssueWarning {
+			warnings = append(warnings, is)
+		}
+	}
+	return warnings
+}
+
+func (v *Validation) add(level model.IssueLevel, pe *ParseError) {
+	is := model.Issue{
+		Level:     level,
+		Code:      issueCode(pe.Err),
+		Message:   pe.Err.Error(),
+		Statement: pe.Statement,
+		Field:     pe.Field,
+	}
+	if pe.Position.Line > 0 {
+		pos := pe.Position
+		is.Position = &pos
+	}
+	v.Issues = append(v.Issues, is)
+	if level == model.IssueError {
+		v.errs = append(v.errs, pe)
+	}
+}
+
+// ValidationError lists every issue of a document that failed validation,
+// warnings included. It matches the sentinel of each error with errors.Is.
+type ValidationError struct {
+	Issues []model.Issue
+
+	errs []*ParseError
+}
+
+func (e *ValidationError) Error() string {
+	msg := e.errs[0].Error()
+	if n := len(e.errs) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.errs))
+	for i, pe := range e.errs {
+		errs[i] = pe
+	}
+	return errs
+}
+
+// First returns the earliest error in the document.
+func (e *ValidationError) First() *ParseError {
+	return e.errs[0]
+}
+
+// Validate checks the whole document instead of stopping at the first
+// problem. The returned error is only set when raw is empty or is not a JSON
+// object at all; everything else is reported in the Validation.
+func Validate(raw []byte, opts ...Option) (*Validation, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(raw) > MaxInputBytes {
+		return nil, ErrInputTooLarge
+	}
+	if len(bytes.TrimSpace(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")))) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	var anchors []anchor
+	if o.format == FormatYAML || (o.format == FormatAuto && DetectFormat(raw) == FormatYAML) {
+		converted, a, err := yamlToJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+		raw, anchors = converted, a
+	}
+
+	var policy model.Policy
+	err := json.Unmarshal(raw, &policy)
+	var syn *json.SyntaxError
+	if errors.As(err, &syn) {
+		return nil, syntaxError(raw, syn)
+	}
+
+	src := newSourceMap(raw)
+	src.anchors = anchors
+	if start := len(raw) - len(bytes.TrimLeft(raw, " \t\r\n")); raw[start] != '{' {
+		return nil, &ParseError{Err: ErrNotObject, Statement: -1, Position: src.Position(start)}
+	}
+	v := &Validation{Source: src}
+
+	// When decoding fails, the rest of the checks run on whatever still
+	// decodes, skipping the parts already reported.
+	broken := make(map[int]bool)
+	brokenFields := make(map[string]bool)
+	if err != nil {
+		for _, pe := range src.decodeErrors(err) {
+			v.add(model.IssueError, pe)
+			if pe.Statement >= 0 {
+				broken[pe.Statement] = true
+			} else {
+				brokenFields[strings.ToLower(pe.Field)] = true
+			}
+		}
+		policy = src.partialPolicy()
+	}
+	if o.strict {
+		v.checkKeys(-1, src.keys, policyKeys)
+	}
+
+	switch {
+	case brokenFields["version"]:
+	case policy.Version == "":
+		v.add(model.IssueError, src.errorAt(-1, "Version", ErrMissingVersion))
+	case policy.Version == "2008-10-17":
+		v.add(model.IssueWarning, src.errorAt(-1, "Version", fmt.Errorf("%w %q: policy variables are not supported, use 2012-10-17", ErrLegacyVersion, policy.Version)))
+	case policy.Version != "2012-10-17":
+		v.add(model.IssueError, src.errorAt(-1, "Version", fmt.Errorf("%w: %q", ErrUnsupportedVersion, policy.Version)))
+	}
+
+	if len(policy.Statement) == 0 && !brokenFields["statement"] && len(broken) == 0 {
+		v.add(model.IssueError, src.errorAt(-1, "Statement", ErrMissingStatement))
+	}
+
+	sids := make(map[string]int)
+	for i, stmt := range policy.Statement {
+		if o.strict && i < len(src.Statements) {
+			v.checkKeys(i, src.Statements[i].keys, statementKeys)
+			v.checkNestedKeys(i, src.Statements[i].nested)
+		}
+		if broken[i] {
+			continue
+		}
+		v.checkStatement(i, stmt)
+
+		if stmt.Sid == "" {
+			v.add(model.IssueWarning, src.errorAt(i, "", fmt.Errorf("statement %d: %w", i, ErrMissingSid)))
+		} else if first, dup := sids[stmt.Sid]; dup {
+			v.add(model.IssueWarning, src.errorAt(i, "Sid", fmt.Errorf("%w %q: statements %d and %d", ErrDuplicateSid, stmt.Sid, first, i)))
+		} else {
+			sids[stmt.Sid] = i
+		}
+	}
+
+	if len(v.errs) == 0 {
+		v.Policy = &policy
+	}
+	return v, nil
+}
+
+func (v *Validation) checkStatement(i int, stmt model.Statement) {
+	if stmt.Effect != "Allow" && stmt.Effect != "Deny" {
+		v.add(model.IssueError, v.Source.errorAt(i, "Effect", fmt.Errorf("%w: statement %d has Effect %q", ErrInvalidEffect, i, stmt.Effect)))
+	}
+
+	if len(stmt.Action) == 0 && len(stmt.NotAction) == 0 {
+		v.add(model.IssueError, v.Source.errorAt(i, "", fmt.Errorf("statement %d %w", i, ErrMissingAction)))
+	}
+
+	if len(stmt.Resource) == 0 && len(stmt.NotResource) == 0 && stmt.Principal == nil {
+		v.add(model.IssueError, v.Source.errorAt(i, "", fmt.Errorf("statement %d %w", i, ErrMissingResource)))
+	}
+}
+
+// partialPolicy decodes the fields and statements that are valid on their own,
+// leaving the others zero.
+func (m *SourceMap) partialPolicy() model.Policy {
+	var p model.Policy
+	for _, field := range fieldsInOrder(m.Fields) {
+		if strings.EqualFold(field, "Statement") {
+			continue
+		}
+		span := m.Fields[field]
+		json.Unmarshal(wrapField(field, m.input[span.Start:span.End]), &p)
+	}
+
+	p.Statement = make([]model.Statement, len(m.Statements))
+	for i, st := range m.Statements {
+		json.Unmarshal(m.input[st.Start:st.End], &p.Statement[i])
+	}
+	return p
+}
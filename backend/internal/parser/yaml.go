@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+var ErrInvalidYAML = errors.New("invalid YAML")
+
+// maxExpandedBytes bounds the JSON produced from YAML. Aliases are expanded
+// in place, so a small document of nested aliases would otherwise grow
+// exponentially.
+const maxExpandedBytes = 4 * MaxInputBytes
+
+type Format int
+
+const (
+	FormatAuto Format = iota
+	FormatJSON
+	FormatYAML
+)
+
+// WithFormat skips format detection.
+func WithFormat(f Format) Option {
+	return func(o *options) { o.format = f }
+}
+
+// DetectFormat treats a document as JSON when it starts with an object or
+// array, and as YAML otherwise.
+func DetectFormat(raw []byte) Format {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// anchor ties an offset in the JSON converted from YAML to the position of the
+// YAML node it was produced from.
+type anchor struct {
+	offset int
+	pos    model.Position
+}
+
+func (m *SourceMap) anchorPosition(offset int) model.Position {
+	i := sort.Search(len(m.anchors), func(i int) bool { return m.anchors[i].offset > offset })
+	if i == 0 {
+		return model.Position{Line: 1, Column: 1}
+	}
+	return m.anchors[i-1].pos
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlToJSON converts the first document in raw to JSON, recording where each
+// value and key came from so positions can be reported against the YAML.
+func yamlToJSON(raw []byte) ([]byte, []anchor, error) {
+	lines := lineStarts(raw)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		pe := &ParseError{Err: fmt.Errorf("%w: %v", ErrInvalidYAML, err), Statement: -1}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			if line, _ := strconv.Atoi(m[1]); line >= 1 && line <= len(lines) {
+				pe.Position = model.Position{Offset: lines[line-1], Line: line, Column: 1}
+			}
+		}
+		return nil, nil, pe
+	}
+
+	c := &yamlConverter{src: raw, lines: lines}
+	if doc.Kind == 0 {
+		c.buf.WriteString("null")
+	} else if err := c.node(&doc); err != nil {
+		return nil, nil, &ParseError{Err: fmt.Errorf("%w: %v", ErrInvalidYAML, err), Statement: -1}
+	}
+	return c.buf.Bytes(), c.anchors, nil
+}
+
+type yamlConverter struct {
+	buf     bytes.Buffer
+	anchors []anchor
+	src     []byte
+	lines   []int
+}
+
+func (c *yamlConverter) mark(n *yaml.Node) {
+	pos := model.Position{Line: n.Line, Column: n.Column}
+	if n.Line >= 1 && n.Line <= len(c.lines) {
+		start := c.lines[n.Line-1]
+		offset := start
+		for col := 1; col < n.Column && offset < len(c.src) && c.src[offset] != '\n'; col++ {
+			_, size := utf8.DecodeRune(c.src[offset:])
+			offset += size
+		}
+		pos.Offset = offset
+	}
+	c.anchors = append(c.anchors, anchor{offset: c.buf.Len(), pos: pos})
+}
+
+func (c *yamlConverter) node(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			c.buf.WriteString("null")
+			return nil
+		}
+		return c.node(n.Content[0])
+
+	case yaml.AliasNode:
+		if c.buf.Len() > maxExpandedBytes {
+			return fmt.Errorf("line %d: aliases expand beyond %d bytes", n.Line, maxExpandedBytes)
+		}
+		c.mark(n)
+		return c.node(n.Alias)
+
+	case yaml.MappingNode:
+		c.mark(n)
+		c.buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			key := n.Content[i]
+			c.mark(key)
+			name, _ := json.Marshal(key.Value)
+			c.buf.Write(name)
+			c.buf.WriteByte(':')
+			if err := c.node(n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte('}')
+		return nil
+
+	case yaml.SequenceNode:
+		c.mark(n)
+		c.buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			if err := c.node(item); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte(']')
+		return nil
+
+	case yaml.ScalarNode:
+		c.mark(n)
+		c.buf.Write(scalarJSON(n))
+		return nil
+	}
+	return fmt.Errorf("line %d: unsupported node", n.Line)
+}
+
+// scalarJSON keeps nulls, booleans and numbers typed and turns everything
+// else, timestamps and custom tags included, into strings, so an unquoted
+// Version: 2012-10-17 still reads as the version string.
+func scalarJSON(n *yaml.Node) []byte {
+	switch n.ShortTag() {
+	case "!!null":
+		return []byte("null")
+	case "!!bool", "!!int", "!!float":
+		var v any
+		if n.Decode(&v) == nil {
+			if out, err := json.Marshal(v); err == nil {
+				return out
+			}
+		}
+	}
+	out, _ := json.Marshal(n.Value)
+	return out
+}
+
+// MarshalYAML writes a policy as YAML, keeping the key order of its JSON form.
+func MarshalYAML(p *model.Policy) ([]byte, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := jsonToYAML(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func jsonToYAML(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			n.Kind, n.Tag = yaml.MappingNode, "!!map"
+		}
+		for dec.More() {
+			if n.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			child, err := jsonToYAML(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
@@ -0,0 +1,105 @@
+package parser_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+)
+
+const yamlPolicy = `# bucket readers
+Version: 2012-10-17
+Statement:
+  - Sid: Read
+    Effect: Allow
+    Action:
+      - s3:GetObject
+      - s3:ListBucket
+    Resource: "arn:aws:s3:::bucket/*"
+    Condition:
+      NumericLessThan:
+        s3:max-keys: 10
+`
+
+func TestParse_YAML(t *testing.T) {
+	p, src, err := parser.ParseSource([]byte(yamlPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Version != "2012-10-17" {
+		t.Errorf("expected version 2012-10-17, got %q", p.Version)
+	}
+	if len(p.Statement) != 1 || len(p.Statement[0].Action) != 2 || p.Statement[0].Sid != "Read" {
+		t.Fatalf("unexpected statements: %+v", p.Statement)
+	}
+
+	r, ok := src.Range(0, "Resource")
+	if !ok || r.Start.Line != 9 || r.Start.Column != 15 {
+		t.Errorf("expected Resource at 9:15, got %+v", r.Start)
+	}
+}
+
+func TestParse_YAMLErrorPositions(t *testing.T) {
+	raw := []byte("Version: 2012-10-17\nStatement:\n  - Effect: Permit\n    Action: s3:*\n    Resource: \"*\"\n")
+	_, err := parser.Parse(raw)
+	var pe *parser.ParseError
+	if !errors.Is(err, parser.ErrInvalidEffect) || !errors.As(err, &pe) {
+		t.Fatalf("expected an invalid effect ParseError, got %v", err)
+	}
+	if pe.Position.Line != 3 || pe.Position.Column != 13 {
+		t.Errorf("expected 3:13, got %d:%d", pe.Position.Line, pe.Position.Column)
+	}
+
+	_, err = parser.Parse([]byte("Version: 2012-10-17\nStatement:\n  - Effect: Allow\n   Action: [s3:*\n"))
+	if !errors.Is(err, parser.ErrInvalidYAML) || !errors.As(err, &pe) || pe.Position.Line == 0 {
+		t.Errorf("expected a positioned YAML error, got %v", err)
+	}
+}
+
+func TestParse_YAMLAliasExpansion(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("a0: &a0 [s3:GetObject, s3:GetObject, s3:GetObject, s3:GetObject]\n")
+	for i := 1; i <= 12; i++ {
+		prev := fmt.Sprintf("*a%d", i-1)
+		fmt.Fprintf(&b, "a%d: &a%d [%s, %s, %s, %s]\n", i, i, prev, prev, prev, prev)
+	}
+	b.WriteString("Version: 2012-10-17\nStatement: []\n")
+
+	if _, err := parser.Parse([]byte(b.String())); !errors.Is(err, parser.ErrInvalidYAML) {
+		t.Errorf("expected nested aliases to be rejected, got %v", err)
+	}
+}
+
+func TestParse_FormatOption(t *testing.T) {
+	if _, err := parser.Parse([]byte(yamlPolicy), parser.WithFormat(parser.FormatJSON)); !errors.Is(err, parser.ErrInvalidJSON) {
+		t.Errorf("expected YAML to be rejected as JSON, got %v", err)
+	}
+	raw := []byte(`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}`)
+	if _, err := parser.Parse(raw, parser.WithFormat(parser.FormatYAML)); err != nil {
+		t.Errorf("JSON is valid YAML, got %v", err)
+	}
+}
+
+func TestMarshalYAML_RoundTrip(t *testing.T) {
+	p, err := parser.Parse([]byte(yamlPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := parser.MarshalYAML(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "Version: \"2012-10-17\"\n") {
+		t.Errorf("expected a quoted version first, got:\n%s", out)
+	}
+
+	again, err := parser.Parse(out)
+	if err != nil {
+		t.Fatalf("round trip failed: %v\n%s", err, out)
+	}
+	if again.Statement[0].Sid != "Read" || len(again.Statement[0].Action) != 2 {
+		t.Errorf("round trip changed the policy:\n%s", out)
+	}
+}
@@ -0,0 +1,139 @@
+package permissions
+
+import (
+	"sort"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+// Enumerate expands every statement against the catalog. The policy-wide
+// Granted list is the union of all Allow statements minus the actions that a
+// Deny removes everywhere: Denies scoped by resource, principal or condition
+// only take effect for some requests and therefore leave the action in place.
+func Enumerate(p *model.Policy, cat *catalog.Catalog) model.PermissionsResponse {
+	resp := model.PermissionsResponse{
+		CatalogVersion: cat.Version,
+		CatalogPartial: cat.Partial(),
+		Statements:     make([]model.StatementPermissions, 0, len(p.Statement)),
+	}
+
+	allowed := make(map[string]catalog.QualifiedAction)
+	denied := make(map[string]bool)
+
+	for i, s := range p.Statement {
+		actions := StatementActions(s, cat)
+
+		resp.Statements = append(resp.Statements, model.StatementPermissions{
+			Index:       i,
+			Effect:      s.Effect,
+			ActionCount: len(actions),
+			Groups:      Group(actions),
+			Unmatched:   unmatchedPatterns(s.Action, cat),
+		})
+
+		switch {
+		case s.Effect == "Allow":
+			for _, a := range actions {
+				allowed[a.String()] = a
+			}
+		case s.Effect == "Deny" && graph.Unrestricted(s):
+			for _, a := range actions {
+				denied[a.String()] = true
+			}
+		}
+	}
+
+	granted := make([]catalog.QualifiedAction, 0, len(allowed))
+	for name, a := range allowed {
+		if !denied[name] {
+			granted = append(granted, a)
+		}
+	}
+	resp.Granted = Group(granted)
+	resp.GrantedCount = len(granted)
+
+	return resp
+}
+
+// StatementActions returns the catalog actions a statement applies to,
+// treating NotAction as the complement of its patterns.
+func StatementActions(s model.Statement, cat *catalog.Catalog) []catalog.QualifiedAction {
+	if len(s.Action) > 0 {
+		seen := make(map[string]bool)
+		var result []catalog.QualifiedAction
+		for _, pattern := range s.Action {
+			for _, a := range cat.Expand(pattern) {
+				if !seen[a.String()] {
+					seen[a.String()] = true
+					result = append(result, a)
+				}
+			}
+		}
+		return result
+	}
+
+	if len(s.NotAction) > 0 {
+		var result []catalog.QualifiedAction
+		for _, a := range cat.All() {
+			if !matchesAny(s.NotAction, a.String()) {
+				result = append(result, a)
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+func Group(actions []catalog.QualifiedAction) []model.ActionGroup {
+	type key struct{ service, level string }
+	buckets := make(map[key][]string)
+	for _, a := range actions {
+		k := key{a.Service, a.AccessLevel}
+		buckets[k] = append(buckets[k], a.String())
+	}
+
+	groups := make([]model.ActionGroup, 0, len(buckets))
+	for k, names := range buckets {
+		sort.Strings(names)
+		groups = append(groups, model.ActionGroup{Service: k.service, AccessLevel: k.level, Actions: names})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Service != groups[j].Service {
+			return groups[i].Service < groups[j].Service
+		}
+		return levelRank(groups[i].AccessLevel) < levelRank(groups[j].AccessLevel)
+	})
+	return groups
+}
+
+func unmatchedPatterns(patterns []string, cat *catalog.Catalog) []string {
+	var result []string
+	for _, pattern := range patterns {
+		if len(cat.Expand(pattern)) == 0 {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if graph.Match(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func levelRank(level string) int {
+	for i, l := range catalog.AccessLevels {
+		if l == level {
+			return i
+		}
+	}
+	return len(catalog.AccessLevels)
+}
@@ -0,0 +1,99 @@
+package permissions_test
+
+import (
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/permissions"
+)
+
+func testCatalog(t *testing.T) *catalog.Catalog {
+	t.Helper()
+	c, err := catalog.Parse([]byte(`{"version": "test", "services": [
+		{"prefix": "s3", "name": "S3", "actions": [
+			{"name": "GetObject", "accessLevel": "Read"},
+			{"name": "ListBucket", "accessLevel": "List"},
+			{"name": "PutObject", "accessLevel": "Write"},
+			{"name": "PutBucketPolicy", "accessLevel": "Permissions management"}
+		]},
+		{"prefix": "iam", "name": "IAM", "actions": [
+			{"name": "GetUser", "accessLevel": "Read"},
+			{"name": "TagUser", "accessLevel": "Tagging"}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestEnumerate_GroupsByServiceAndLevel(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:*", "iam:Get*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	resp := permissions.Enumerate(p, testCatalog(t))
+
+	if resp.CatalogVersion != "test" {
+		t.Errorf("expected catalog version test, got %s", resp.CatalogVersion)
+	}
+	if !resp.CatalogPartial {
+		t.Error("expected a catalog without complete services to be reported as partial")
+	}
+	if resp.GrantedCount != 5 {
+		t.Fatalf("expected 5 granted actions, got %d", resp.GrantedCount)
+	}
+
+	var order []string
+	for _, g := range resp.Granted {
+		order = append(order, g.Service+"/"+g.AccessLevel)
+	}
+	want := []string{"iam/Read", "s3/List", "s3/Read", "s3/Write", "s3/Permissions management"}
+	if len(order) != len(want) {
+		t.Fatalf("expected groups %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("group %d: expected %s, got %s", i, want[i], order[i])
+		}
+	}
+}
+
+func TestEnumerate_NotActionAndDeny(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", NotAction: model.StringOrSlice{"iam:*"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:PutBucketPolicy"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"s3:PutObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::logs/*"}},
+		},
+	}
+
+	resp := permissions.Enumerate(p, testCatalog(t))
+
+	if resp.Statements[0].ActionCount != 4 {
+		t.Errorf("expected NotAction statement to cover 4 actions, got %d", resp.Statements[0].ActionCount)
+	}
+	// The resource-scoped Deny only removes PutObject for some resources.
+	if resp.GrantedCount != 3 {
+		t.Errorf("expected 3 granted actions, got %d: %+v", resp.GrantedCount, resp.Granted)
+	}
+}
+
+func TestEnumerate_Unmatched(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObjet", "s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	resp := permissions.Enumerate(p, testCatalog(t))
+	if len(resp.Statements[0].Unmatched) != 1 || resp.Statements[0].Unmatched[0] != "s3:GetObjet" {
+		t.Errorf("expected unmatched [s3:GetObjet], got %v", resp.Statements[0].Unmatched)
+	}
+}
@@ -0,0 +1,309 @@
+package pipeline
+
+import (
+	"errors"
+
+	"github.com/Kuba0517/iam-analyzer/internal/account"
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/arn"
+	"github.com/Kuba0517/iam-analyzer/internal/cfn"
+	"github.com/Kuba0517/iam-analyzer/internal/diff"
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/normalizer"
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+	"github.com/Kuba0517/iam-analyzer/internal/scorer"
+	"github.com/Kuba0517/iam-analyzer/internal/simplifier"
+	"github.com/Kuba0517/iam-analyzer/internal/terraform"
+)
+
+// Result is an analysis together with where each original statement sits in
+// the input.
+type Result struct {
+	*model.AnalyzeResponse
+	Source *parser.SourceMap
+}
+
+// Analyze runs parser → normalizer → scorer/analyzer/simplifier over a raw
+// policy document. It is shared by the HTTP handlers and the CLI.
+func Analyze(raw []byte, opts ...parser.Option) (*model.AnalyzeResponse, error) {
+	res, err := Run(raw, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.AnalyzeResponse, nil
+}
+
+// Run validates the whole document first, so a policy with several problems
+// fails with a *parser.ValidationError listing all of them.
+func Run(raw []byte, opts ...parser.Option) (*Result, error) {
+	return run(raw, "", opts)
+}
+
+// run is Run for a policy owned by account owner, or by an unknown account
+// when owner is "".
+func run(raw []byte, owner string, opts []parser.Option) (*Result, error) {
+	v, err := parser.Validate(raw, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	resp := analyze(v.Policy, owner)
+	resp.Warnings = v.Warnings()
+	locate(resp.Findings, v.Source)
+	return &Result{AnalyzeResponse: resp, Source: v.Source}, nil
+}
+
+// Parse validates a policy document the way Run does, without analyzing it.
+func Parse(raw []byte, opts ...parser.Option) (*model.Policy, error) {
+	v, err := parser.Validate(raw, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return v.Policy, nil
+}
+
+// locate fills in Finding.Locations from the original statement indices.
+func locate(findings []model.Finding, source *parser.SourceMap) {
+	for i := range findings {
+		var locs []model.SourceRange
+		for _, idx := range findings[i].OriginalIndices {
+			if r, ok := source.Range(idx, ""); ok {
+				locs = append(locs, r)
+			}
+		}
+		findings[i].Locations = locs
+	}
+}
+
+// AnalyzeTemplate analyzes every policy document embedded in a CloudFormation
+// template. A document that fails to parse is reported on its own entry.
+func AnalyzeTemplate(raw []byte, opts ...parser.Option) (*model.TemplateResponse, error) {
+	docs, err := cfn.Extract(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]parser.Option{parser.WithFormat(parser.FormatJSON)}, opts...)
+	resp := &model.TemplateResponse{Policies: make([]model.TemplatePolicy, 0, len(docs))}
+	for _, d := range docs {
+		tp := model.TemplatePolicy{
+			LogicalID:    d.LogicalID,
+			ResourceType: d.ResourceType,
+			Path:         d.Path,
+			PolicyName:   d.PolicyName,
+		}
+
+		tp.Analysis, tp.Error, tp.Issues = analyzeEmbedded(d.Policy, opts)
+		resp.Policies = append(resp.Policies, tp)
+	}
+	return resp, nil
+}
+
+// AnalyzeTerraform analyzes the policies of terraform show -json output or of
+// the aws_iam_policy_document blocks in an HCL file, keyed by resource address.
+func AnalyzeTerraform(raw []byte, filename string, opts ...parser.Option) (*model.TerraformResponse, error) {
+	docs, err := terraform.Extract(raw, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]parser.Option{parser.WithFormat(parser.FormatJSON)}, opts...)
+	resp := &model.TerraformResponse{Policies: make([]model.TerraformPolicy, 0, len(docs))}
+	for _, d := range docs {
+		tp := model.TerraformPolicy{
+			Address:      d.Address,
+			ResourceType: d.Type,
+			Attribute:    d.Attribute,
+			PolicyName:   d.Name,
+			File:         d.File,
+			Line:         d.Line,
+		}
+		tp.Analysis, tp.Error, tp.Issues = analyzeEmbedded(d.Policy, opts)
+		resp.Policies = append(resp.Policies, tp)
+	}
+	return resp, nil
+}
+
+// analyzeEmbedded analyzes a policy extracted from a larger document. On
+// failure it returns the error message and any validation issues instead.
+func analyzeEmbedded(policy []byte, opts []parser.Option) (*model.AnalyzeResponse, string, []model.Issue) {
+	res, err := Run(policy, opts...)
+	if err != nil {
+		var ve *parser.ValidationError
+		if errors.As(err, &ve) {
+			return nil, err.Error(), ve.Issues
+		}
+		return nil, err.Error(), nil
+	}
+
+	// Positions would point into the extracted document, not the file it
+	// came from.
+	for i := range res.Findings {
+		res.Findings[i].Locations = nil
+	}
+	return res.AnalyzeResponse, "", nil
+}
+
+// AnalyzeAccount analyzes every user, group, role and managed policy of an
+// account authorization details dump. Each policy document is analyzed on its
+// own, and each entity is scored on all the policies that apply to it
+// combined, group policies included for users.
+func AnalyzeAccount(raw []byte, opts ...parser.Option) (*model.AccountResponse, error) {
+	dump, err := account.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]parser.Option{parser.WithFormat(parser.FormatJSON)}, opts...)
+	analyzed := make(map[string]*model.AnalyzeResponse)
+	entities := dump.Entities()
+	resp := &model.AccountResponse{Entities: make([]model.AccountEntity, 0, len(entities))}
+	for _, e := range entities {
+		ae := model.AccountEntity{
+			Type:     e.Type,
+			Name:     e.Name,
+			Arn:      e.Arn,
+			Policies: make([]model.AccountPolicy, 0, len(e.Sources)),
+			Missing:  e.Missing,
+		}
+
+		combined := &model.Policy{Version: "2012-10-17"}
+		for i, src := range e.Sources {
+			ap := model.AccountPolicy{Name: src.Name, Arn: src.Arn, Kind: src.Kind, Via: src.Via}
+			// Managed policies are shared, so each is analyzed once.
+			ap.Analysis = analyzed[src.Arn]
+			if ap.Analysis == nil {
+				var p *model.Policy
+				p, ap.Error, ap.Issues = validate(src.Document, opts)
+				if p != nil {
+					ap.Analysis = analyze(p, "")
+					if src.Arn != "" {
+						analyzed[src.Arn] = ap.Analysis
+					}
+				}
+			}
+			if ap.Analysis != nil {
+				for j, stmt := range ap.Analysis.Original.Statement {
+					combined.Statement = append(combined.Statement, stmt)
+					ae.Statements = append(ae.Statements, model.AccountStatement{Policy: i, Statement: j})
+				}
+			}
+			ae.Policies = append(ae.Policies, ap)
+		}
+		if len(combined.Statement) > 0 {
+			ae.Analysis = analyzeCombined(combined, ae.Statements)
+		}
+
+		if e.Trust != nil {
+			// The role's own account is not a cross-account principal.
+			trust, err := run(e.Trust, arn.Account(e.Arn), opts)
+			if err != nil {
+				ae.TrustError = err.Error()
+			} else {
+				ae.Trust = trust.AnalyzeResponse
+			}
+		}
+		resp.Entities = append(resp.Entities, ae)
+	}
+	return resp, nil
+}
+
+// analyzeCombined analyzes the statements of all policies of an entity
+// together, for the effective score and the findings that span policies.
+// Findings within one policy are left to that policy's analysis, and so are
+// suggestions, since a patch cannot edit several documents at once.
+func analyzeCombined(combined *model.Policy, origins []model.AccountStatement) *model.AnalyzeResponse {
+	a := analyze(combined, "")
+	a.Suggestions = []model.Patch{}
+
+	findings := make([]model.Finding, 0, len(a.Findings))
+	for _, f := range a.Findings {
+		policies := make(map[int]bool)
+		for _, i := range f.OriginalIndices {
+			policies[origins[i].Policy] = true
+		}
+		if len(policies) != 1 {
+			findings = append(findings, f)
+		}
+	}
+	a.Findings = findings
+	return a
+}
+
+// validate parses a policy, returning the error message and issues instead
+// when it is not valid.
+func validate(raw []byte, opts []parser.Option) (*model.Policy, string, []model.Issue) {
+	v, err := parser.Validate(raw, opts...)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+	if err := v.Err(); err != nil {
+		return nil, err.Error(), v.Issues
+	}
+	return v.Policy, "", nil
+}
+
+func analyze(policy *model.Policy, owner string) *model.AnalyzeResponse {
+	normalized, mapping := normalizer.NormalizeWithMapping(policy)
+	// Building the graph is the expensive part; it is built once and
+	// shared by the scorer, analyzer, simplifier and serializer.
+	g := graph.Build(normalized)
+	score := scorer.ScoreWithGraph(normalized, g, mapping)
+	ctx := analyzer.NewContextWithGraph(normalized, g, mapping)
+	ctx.Account = owner
+	findings := analyzer.AnalyzeContext(ctx)
+	suggestions := simplifier.SuggestWithGraph(normalized, g, mapping)
+
+	for i := range suggestions {
+		result := suggestions[i].Apply(normalized)
+		preview, err := diff.Unified("normalized", normalized, "simplified", result)
+		if err == nil {
+			suggestions[i].DiffPreview = preview
+		}
+	}
+
+	graphData := graph.SerializeWithMapping(g, normalized, mapping)
+
+	return &model.AnalyzeResponse{
+		Original:    policy,
+		Normalized:  normalized,
+		Score:       score,
+		Findings:    findings,
+		Suggestions: suggestions,
+		Graph:       &graphData,
+		Mapping:     mapping,
+	}
+}
+
+// Apply normalizes the policy and applies the selected suggestions to it.
+func Apply(policy *model.Policy, patchIDs []string) *model.ApplyResponse {
+	normalized := normalizer.Normalize(policy)
+	suggestions := simplifier.Suggest(normalized)
+	simplified := simplifier.Apply(normalized, suggestions, patchIDs)
+
+	g := graph.Build(simplified)
+	score := scorer.ScoreWithGraph(simplified, g, model.IdentityMapping(simplified))
+	findings := analyzer.AnalyzeContext(analyzer.NewContextWithGraph(simplified, g, nil))
+	graphData := graph.Serialize(g, simplified)
+
+	return &model.ApplyResponse{
+		Simplified: simplified,
+		Score:      score,
+		Findings:   findings,
+		Graph:      &graphData,
+	}
+}
+
+func PatchIDs(patches []model.Patch) []string {
+	ids := make([]string, len(patches))
+	for i, p := range patches {
+		ids[i] = p.ID
+	}
+	return ids
+}
@@ -0,0 +1,217 @@
+package pipeline_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+	"github.com/Kuba0517/iam-analyzer/internal/pipeline"
+)
+
+const dump = `{
+  "UserDetailList": [{
+    "UserName": "alice",
+    "Arn": "arn:aws:iam::123456789012:user/alice",
+    "GroupList": [],
+    "UserPolicyList": [{
+      "PolicyName": "no-s3",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": "s3:*", "Resource": "*"}]}
+    }],
+    "AttachedManagedPolicies": [{"PolicyName": "Admin", "PolicyArn": "arn:aws:iam::123456789012:policy/Admin"}]
+  }],
+  "GroupDetailList": [],
+  "RoleDetailList": [{
+    "RoleName": "app",
+    "Arn": "arn:aws:iam::123456789012:role/app",
+    "AssumeRolePolicyDocument": {"Version": "2012-10-17", "Statement": [{
+      "Effect": "Allow",
+      "Principal": {"AWS": ["arn:aws:iam::123456789012:root", "arn:aws:iam::444455556666:root"]},
+      "Action": "sts:AssumeRole"
+    }]},
+    "RolePolicyList": [{
+      "PolicyName": "broken",
+      "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Maybe", "Action": "s3:GetObject", "Resource": "*"}]}
+    }],
+    "AttachedManagedPolicies": []
+  }],
+  "Policies": [{
+    "PolicyName": "Admin",
+    "Arn": "arn:aws:iam::123456789012:policy/Admin",
+    "DefaultVersionId": "v1",
+    "PolicyVersionList": [
+      {"VersionId": "v1", "IsDefaultVersion": true, "Document": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}}
+    ]
+  }]
+}`
+
+func hasFinding(a *model.AnalyzeResponse, title string) bool {
+	for _, f := range a.Findings {
+		if f.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyzeAccount(t *testing.T) {
+	resp, err := pipeline.AnalyzeAccount([]byte(dump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entities := make(map[string]model.AccountEntity)
+	for _, e := range resp.Entities {
+		entities[e.Type+"/"+e.Name] = e
+	}
+
+	alice := entities["user/alice"]
+	if len(alice.Policies) != 2 || alice.Policies[0].Analysis == nil || alice.Policies[1].Analysis == nil {
+		t.Fatalf("expected both policies of alice to be analyzed, got %+v", alice.Policies)
+	}
+	if !hasFinding(alice.Policies[1].Analysis, "Full wildcard statement") {
+		t.Error("expected the managed policy's own analysis to report its wildcard")
+	}
+	if alice.Policies[1].Analysis != entities["policy/Admin"].Policies[0].Analysis {
+		t.Error("expected the managed policy to be analyzed once")
+	}
+	if len(alice.Statements) != 2 || alice.Statements[1] != (model.AccountStatement{Policy: 1, Statement: 0}) {
+		t.Errorf("unexpected statement origins %+v", alice.Statements)
+	}
+}
+
+func TestAnalyzeAccount_Combined(t *testing.T) {
+	resp, err := pipeline.AnalyzeAccount([]byte(dump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice := resp.Entities[0]
+	combined := alice.Analysis
+	if combined == nil {
+		t.Fatal("expected a combined analysis")
+	}
+
+	if !hasFinding(combined, "Deny/Allow overlap") {
+		t.Error("expected the Deny and Allow from different policies to overlap")
+	}
+	for _, f := range combined.Findings {
+		policies := make(map[int]bool)
+		for _, i := range f.OriginalIndices {
+			policies[alice.Statements[i].Policy] = true
+		}
+		if len(policies) < 2 {
+			t.Errorf("expected only findings spanning policies, got %q on %v", f.Title, f.OriginalIndices)
+		}
+	}
+	if combined.Suggestions == nil || len(combined.Suggestions) != 0 {
+		t.Errorf("expected no suggestions, got %v", combined.Suggestions)
+	}
+}
+
+func TestAnalyzeAccount_Role(t *testing.T) {
+	resp, err := pipeline.AnalyzeAccount([]byte(dump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var app model.AccountEntity
+	for _, e := range resp.Entities {
+		if e.Type == "role" {
+			app = e
+		}
+	}
+
+	broken := app.Policies[0]
+	if broken.Analysis != nil || broken.Error == "" || len(broken.Issues) == 0 {
+		t.Errorf("expected the invalid policy to carry its error and issues, got %+v", broken)
+	}
+	if app.Analysis != nil {
+		t.Error("expected no combined analysis without a valid policy")
+	}
+
+	if app.Trust == nil {
+		t.Fatalf("expected the trust policy to be analyzed, got error %q", app.TrustError)
+	}
+	var evidence string
+	for _, f := range app.Trust.Findings {
+		if f.Title == "Cross-account trust without ExternalId" {
+			evidence = f.Evidence
+		}
+	}
+	if !strings.Contains(evidence, "444455556666") || strings.Contains(evidence, "123456789012") {
+		t.Errorf("expected only the other account to be reported, got %q", evidence)
+	}
+}
+
+const template = `{
+  "Resources": {
+    "Admin": {
+      "Type": "AWS::IAM::ManagedPolicy",
+      "Properties": {
+        "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}
+      }
+    },
+    "Broken": {
+      "Type": "AWS::IAM::ManagedPolicy",
+      "Properties": {
+        "PolicyDocument": {"Version": "2012-10-17", "Statement": [{"Effect": "Maybe", "Action": "s3:GetObject", "Resource": "*"}]}
+      }
+    }
+  }
+}`
+
+func TestAnalyzeTemplate(t *testing.T) {
+	resp, err := pipeline.AnalyzeTemplate([]byte(template))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Policies) != 2 {
+		t.Fatalf("expected 2 policies, got %d", len(resp.Policies))
+	}
+
+	for _, tp := range resp.Policies {
+		switch tp.LogicalID {
+		case "Admin":
+			if tp.Analysis == nil || len(tp.Analysis.Findings) == 0 {
+				t.Fatalf("expected findings for Admin, got error %q", tp.Error)
+			}
+			for _, f := range tp.Analysis.Findings {
+				if f.Locations != nil {
+					t.Errorf("expected no locations in the extracted document, got %v", f.Locations)
+				}
+			}
+		case "Broken":
+			if tp.Analysis != nil || !strings.Contains(tp.Error, "Effect") || len(tp.Issues) == 0 {
+				t.Errorf("expected Broken to carry its error and issues, got %+v", tp)
+			}
+		default:
+			t.Errorf("unexpected policy %s", tp.LogicalID)
+		}
+	}
+}
+
+func TestRun_Locations(t *testing.T) {
+	raw := []byte(`{
+  "Version": "2012-10-17",
+  "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]
+}`)
+	res, err := pipeline.Run(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Findings) == 0 {
+		t.Fatal("expected findings")
+	}
+	for _, f := range res.Findings {
+		if len(f.Locations) != 1 || f.Locations[0].Start.Line != 3 {
+			t.Errorf("%s: expected a location on line 3, got %v", f.Title, f.Locations)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := pipeline.Parse([]byte(`{"Version": "2012-10-17", "Statement": []}`))
+	var ve *parser.ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, parser.ErrMissingStatement) {
+		t.Errorf("expected a validation error for a missing Statement, got %v", err)
+	}
+}
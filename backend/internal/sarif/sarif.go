@@ -0,0 +1,171 @@
+package sarif
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Kuba0517/iam-analyzer/internal/analyzer"
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName = "iam-analyzer"
+	toolURI  = "https://github.com/Kuba0517/iam-analyzer"
+)
+
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool       Tool     `json:"tool"`
+	ColumnKind string   `json:"columnKind"`
+	Results    []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+	Rules          []Rule `json:"rules"`
+}
+
+type Rule struct {
+	ID                   string        `json:"id"`
+	ShortDescription     Message       `json:"shortDescription"`
+	DefaultConfiguration Configuration `json:"defaultConfiguration"`
+}
+
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID    string     `json:"ruleId,omitempty"`
+	RuleIndex *int       `json:"ruleIndex,omitempty"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// Artifact is one analyzed file. Findings without Locations point at the
+// file as a whole.
+type Artifact struct {
+	URI      string
+	Findings []model.Finding
+}
+
+func Level(s model.Severity) string {
+	switch s {
+	case model.SeverityHigh:
+		return "error"
+	case model.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// Export builds a single-run SARIF log covering every registered rule.
+func Export(artifacts []Artifact) *Log {
+	rules := analyzer.Rules()
+	driver := Driver{Name: toolName, InformationURI: toolURI, Rules: make([]Rule, len(rules))}
+	ruleIndex := make(map[string]int, len(rules))
+	for i, r := range rules {
+		driver.Rules[i] = Rule{
+			ID:                   r.ID(),
+			ShortDescription:     Message{Text: r.Description()},
+			DefaultConfiguration: Configuration{Level: Level(r.DefaultSeverity())},
+		}
+		ruleIndex[r.ID()] = i
+	}
+
+	run := Run{Tool: Tool{Driver: driver}, ColumnKind: "unicodeCodePoints", Results: []Result{}}
+	for _, a := range artifacts {
+		uri := artifactURI(a.URI)
+		for _, f := range a.Findings {
+			res := Result{
+				RuleID:    f.RuleID,
+				Level:     Level(f.Severity),
+				Message:   Message{Text: message(f)},
+				Locations: locations(uri, f.Locations),
+			}
+			if idx, ok := ruleIndex[f.RuleID]; ok {
+				res.RuleIndex = &idx
+			}
+			run.Results = append(run.Results, res)
+		}
+	}
+
+	return &Log{Version: Version, Schema: Schema, Runs: []Run{run}}
+}
+
+func artifactURI(path string) string {
+	uri := filepath.ToSlash(path)
+	if filepath.IsAbs(path) {
+		if !strings.HasPrefix(uri, "/") {
+			uri = "/" + uri
+		}
+		return "file://" + uri
+	}
+	return uri
+}
+
+func message(f model.Finding) string {
+	text := f.Title + ". " + f.Explanation
+	if f.Evidence != "" {
+		text += " " + f.Evidence + "."
+	}
+	return text
+}
+
+func locations(uri string, ranges []model.SourceRange) []Location {
+	var locs []Location
+	for _, r := range ranges {
+		locs = append(locs, Location{PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: uri},
+			Region: &Region{
+				StartLine:   r.Start.Line,
+				StartColumn: r.Start.Column,
+				EndLine:     r.End.Line,
+				EndColumn:   r.End.Column,
+			},
+		}})
+	}
+	if len(locs) == 0 {
+		locs = append(locs, Location{PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: uri}}})
+	}
+	return locs
+}
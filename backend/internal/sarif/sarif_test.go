@@ -0,0 +1,69 @@
+package sarif_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/pipeline"
+	"github.com/Kuba0517/iam-analyzer/internal/sarif"
+)
+
+func TestExport(t *testing.T) {
+	raw := []byte(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Deny", "Action": "s3:DeleteBucket", "Resource": "*"},
+    {"Effect": "Allow", "Action": "*", "Resource": "*"}
+  ]
+}`)
+	res, err := pipeline.Run(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	log := sarif.Export([]sarif.Artifact{{URI: "policies/admin.json", Findings: res.Findings}})
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log header: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) == 0 {
+		t.Fatal("expected rule metadata")
+	}
+
+	var wildcard *sarif.Result
+	for i := range run.Results {
+		if strings.HasPrefix(run.Results[i].Message.Text, "Full wildcard statement") {
+			wildcard = &run.Results[i]
+		}
+	}
+	if wildcard == nil {
+		t.Fatalf("expected a wildcard result, got %+v", run.Results)
+	}
+	if wildcard.Level != "error" {
+		t.Errorf("expected level error, got %s", wildcard.Level)
+	}
+	if run.Tool.Driver.Rules[*wildcard.RuleIndex].ID != "wildcard" {
+		t.Error("ruleIndex does not point at the wildcard rule")
+	}
+
+	loc := wildcard.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "policies/admin.json" {
+		t.Errorf("unexpected uri %q", loc.ArtifactLocation.URI)
+	}
+	// The Allow statement is first after normalization but on line 5 of the input.
+	if loc.Region == nil || loc.Region.StartLine != 5 || loc.Region.StartColumn != 5 {
+		t.Errorf("unexpected region %+v", loc.Region)
+	}
+
+	if _, err := json.Marshal(log); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+}
+
+func TestExport_WithoutSource(t *testing.T) {
+	log := sarif.Export([]sarif.Artifact{{URI: "p.json", Findings: nil}})
+	if log.Runs[0].Results == nil {
+		t.Error("results must be an empty array, not null")
+	}
+}
@@ -2,22 +2,36 @@ package scorer
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/Kuba0517/iam-analyzer/internal/graph"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
 
 func Score(p *model.Policy) model.ScoreResult {
+	return ScoreWithMapping(p, model.IdentityMapping(p))
+}
+
+// ScoreWithMapping is Score for a normalized policy; each breakdown lists the
+// statements that contributed to it, in both numberings.
+func ScoreWithMapping(p *model.Policy, m model.StatementMapping) model.ScoreResult {
+	return ScoreWithGraph(p, graph.Build(p), m)
+}
+
+// ScoreWithGraph is ScoreWithMapping with the graph of p already built.
+func ScoreWithGraph(p *model.Policy, g *graph.Graph, m model.StatementMapping) model.ScoreResult {
 	factors := []model.ScoreBreakdown{
 		statementCount(p),
 		wildcardActionPct(p),
 		wildcardResourcePct(p),
 		negativeStatements(p),
-		denyAllowOverlap(p),
+		denyAllowOverlap(g),
 	}
 
 	total := 0
-	for _, f := range factors {
+	for i, f := range factors {
 		total += f.Score
+		factors[i].OriginalIndices = m.Originals(f.StmtIndices)
 	}
 	if total > 100 {
 		total = 100
@@ -74,21 +88,23 @@ func wildcardActionPct(p *model.Policy) model.ScoreBreakdown {
 		return model.ScoreBreakdown{Label: "Wildcard actions", Value: "0%", Score: 0}
 	}
 
-	count := 0
-	for _, s := range p.Statement {
+	var indices []int
+	for i, s := range p.Statement {
 		for _, a := range s.Action {
 			if a == "*" {
-				count++
+				indices = append(indices, i)
 				break
 			}
 		}
 	}
 
+	count := len(indices)
 	pct := count * 100 / total
 	return model.ScoreBreakdown{
-		Label: "Wildcard actions",
-		Value: fmt.Sprintf("%d%% (%d/%d statements)", pct, count, total),
-		Score: pctToScore(pct),
+		Label:       "Wildcard actions",
+		Value:       fmt.Sprintf("%d%% (%d/%d statements)", pct, count, total),
+		Score:       pctToScore(pct),
+		StmtIndices: indices,
 	}
 }
 
@@ -98,21 +114,23 @@ func wildcardResourcePct(p *model.Policy) model.ScoreBreakdown {
 		return model.ScoreBreakdown{Label: "Wildcard resources", Value: "0%", Score: 0}
 	}
 
-	count := 0
-	for _, s := range p.Statement {
+	var indices []int
+	for i, s := range p.Statement {
 		for _, r := range s.Resource {
 			if r == "*" {
-				count++
+				indices = append(indices, i)
 				break
 			}
 		}
 	}
 
+	count := len(indices)
 	pct := count * 100 / total
 	return model.ScoreBreakdown{
-		Label: "Wildcard resources",
-		Value: fmt.Sprintf("%d%% (%d/%d statements)", pct, count, total),
-		Score: pctToScore(pct),
+		Label:       "Wildcard resources",
+		Value:       fmt.Sprintf("%d%% (%d/%d statements)", pct, count, total),
+		Score:       pctToScore(pct),
+		StmtIndices: indices,
 	}
 }
 
@@ -133,13 +151,17 @@ func pctToScore(pct int) int {
 
 func negativeStatements(p *model.Policy) model.ScoreBreakdown {
 	count := 0
-	for _, s := range p.Statement {
+	var indices []int
+	for i, s := range p.Statement {
 		if len(s.NotAction) > 0 {
 			count++
 		}
 		if len(s.NotResource) > 0 {
 			count++
 		}
+		if len(s.NotAction) > 0 || len(s.NotResource) > 0 {
+			indices = append(indices, i)
+		}
 	}
 
 	pts := count * 5
@@ -148,32 +170,29 @@ func negativeStatements(p *model.Policy) model.ScoreBreakdown {
 	}
 
 	return model.ScoreBreakdown{
-		Label: "Negative statements (NotAction/NotResource)",
-		Value: fmt.Sprintf("%d occurrences", count),
-		Score: pts,
+		Label:       "Negative statements (NotAction/NotResource)",
+		Value:       fmt.Sprintf("%d occurrences", count),
+		Score:       pts,
+		StmtIndices: indices,
 	}
 }
 
-func denyAllowOverlap(p *model.Policy) model.ScoreBreakdown {
-	allowActions := make(map[string]bool)
-	for _, s := range p.Statement {
-		if s.Effect == "Allow" {
-			for _, a := range s.Action {
-				allowActions[a] = true
-			}
-		}
-	}
+// denyAllowOverlap counts the Allow/Deny pairs the graph found applying to
+// some of the same requests, by action, resource and principal.
+func denyAllowOverlap(g *graph.Graph) model.ScoreBreakdown {
+	edges := g.EdgesOfType(graph.DenyAllowOverlap)
+	overlapCount := len(edges)
 
-	overlapCount := 0
-	for _, s := range p.Statement {
-		if s.Effect == "Deny" {
-			for _, a := range s.Action {
-				if allowActions[a] {
-					overlapCount++
-				}
-			}
-		}
+	involved := make(map[int]bool)
+	for _, e := range edges {
+		involved[e.From] = true
+		involved[e.To] = true
+	}
+	var indices []int
+	for i := range involved {
+		indices = append(indices, i)
 	}
+	sort.Ints(indices)
 
 	pts := overlapCount * 5
 	if pts > 20 {
@@ -181,8 +200,9 @@ func denyAllowOverlap(p *model.Policy) model.ScoreBreakdown {
 	}
 
 	return model.ScoreBreakdown{
-		Label: "Deny/Allow overlap",
-		Value: fmt.Sprintf("%d overlapping actions", overlapCount),
-		Score: pts,
+		Label:       "Deny/Allow overlap",
+		Value:       fmt.Sprintf("%d overlapping statement pairs", overlapCount),
+		Score:       pts,
+		StmtIndices: indices,
 	}
 }
@@ -59,6 +59,27 @@ func TestScore_DenyAllowOverlap(t *testing.T) {
 	}
 }
 
+func TestScore_DenyAllowOverlap_FollowsGraph(t *testing.T) {
+	overlapScore := func(stmts ...model.Statement) int {
+		result := scorer.Score(&model.Policy{Version: "2012-10-17", Statement: stmts})
+		for _, b := range result.Breakdown {
+			if b.Label == "Deny/Allow overlap" {
+				return b.Score
+			}
+		}
+		t.Fatal("missing Deny/Allow overlap factor")
+		return 0
+	}
+
+	allow := model.Statement{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::logs/*"}}
+	if got := overlapScore(allow, model.Statement{Effect: "Deny", Action: model.StringOrSlice{"s3:Get*"}, Resource: model.StringOrSlice{"*"}}); got == 0 {
+		t.Error("expected a wildcard Deny over the same action to count")
+	}
+	if got := overlapScore(allow, model.Statement{Effect: "Deny", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::secrets/*"}}); got != 0 {
+		t.Errorf("expected a Deny on other resources not to count, got %d", got)
+	}
+}
+
 func TestScore_NegativeStatements(t *testing.T) {
 	p := &model.Policy{
 		Version: "2012-10-17",
@@ -103,3 +124,26 @@ func TestScore_ManyStatements(t *testing.T) {
 		t.Error("expected statement count score of 15 for 25 statements")
 	}
 }
+
+func TestScoreWithMapping_Indices(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+	mapping := model.StatementMapping{{Normalized: 0, Original: 1}, {Normalized: 1, Original: 0}}
+
+	result := scorer.ScoreWithMapping(p, mapping)
+	for _, b := range result.Breakdown {
+		if b.Label != "Wildcard actions" {
+			continue
+		}
+		if len(b.StmtIndices) != 1 || b.StmtIndices[0] != 1 || len(b.OriginalIndices) != 1 || b.OriginalIndices[0] != 0 {
+			t.Errorf("expected normalized 1 and original 0, got %v and %v", b.StmtIndices, b.OriginalIndices)
+		}
+		return
+	}
+	t.Fatal("missing wildcard actions breakdown")
+}
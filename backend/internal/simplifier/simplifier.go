@@ -4,17 +4,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"strings"
 
+	"github.com/Kuba0517/iam-analyzer/internal/catalog"
 	"github.com/Kuba0517/iam-analyzer/internal/graph"
 	"github.com/Kuba0517/iam-analyzer/internal/model"
 )
 
 func Suggest(p *model.Policy) []model.Patch {
-	g := graph.Build(p)
+	return SuggestWithMapping(p, model.IdentityMapping(p))
+}
+
+// SuggestWithMapping is Suggest for a normalized policy. Patch IDs and
+// StmtIndices still refer to p; titles and OriginalIndices go through mapping.
+func SuggestWithMapping(p *model.Policy, m model.StatementMapping) []model.Patch {
+	return SuggestWithGraph(p, graph.Build(p), m)
+}
+
+// SuggestWithGraph is SuggestWithMapping with the graph of p already built.
+func SuggestWithGraph(p *model.Policy, g *graph.Graph, m model.StatementMapping) []model.Patch {
+	// A statement can be a duplicate, subsumed and dead at once; it gets a
+	// single removal patch so selecting all of them removes it only once.
+	removed := make(map[int]bool)
 
 	var patches []model.Patch
-	patches = append(patches, removeRedundant(p, g)...)
-	patches = append(patches, mergeStatements(p, g)...)
+	patches = append(patches, removeRedundant(p, g, m, removed)...)
+	patches = append(patches, removeSubsumed(p, g, m, removed)...)
+	patches = append(patches, removeDead(p, g, m, removed)...)
+	patches = append(patches, mergeStatements(p, g, m)...)
+	patches = append(patches, fixActionTypos(p, m, catalog.Default())...)
+
+	for i := range patches {
+		patches[i].OriginalIndices = m.Originals(patches[i].StmtIndices)
+	}
 	return patches
 }
 
@@ -34,30 +56,79 @@ func Apply(p *model.Policy, patches []model.Patch, selectedIDs []string) *model.
 	return result
 }
 
-func removeRedundant(p *model.Policy, g *graph.Graph) []model.Patch {
+func removeRedundant(p *model.Policy, g *graph.Graph, m model.StatementMapping, removed map[int]bool) []model.Patch {
 	var patches []model.Patch
 
-	for i, e := range g.EdgesOfType(graph.Redundant) {
+	for _, e := range g.EdgesOfType(graph.Redundant) {
 		removeIdx := e.To
-		id := fmt.Sprintf("dedup-%d", i)
+		if removed[removeIdx] {
+			continue
+		}
+		removed[removeIdx] = true
+		id := fmt.Sprintf("dedup-%d", len(patches))
 
 		patches = append(patches, model.Patch{
 			ID:          id,
-			Title:       fmt.Sprintf("Remove redundant statement %d", removeIdx),
+			Title:       fmt.Sprintf("Remove redundant statement %s", m.Ref(removeIdx)),
 			Impact:      "Removes 1 duplicate statement",
-			DiffPreview: removeDiffPreview(p, removeIdx),
-			Apply: func(policy *model.Policy) *model.Policy {
-				cp := deepCopyPolicy(policy)
-				cp.Statement = slices.Delete(cp.Statement, removeIdx, removeIdx+1)
-				return cp
-			},
+			DiffPreview: removeDiffPreview(p, m, removeIdx),
+			StmtIndices: []int{removeIdx},
+			Apply:       removeStatement(p.Statement[removeIdx]),
+		})
+	}
+
+	return patches
+}
+
+func removeSubsumed(p *model.Policy, g *graph.Graph, m model.StatementMapping, removed map[int]bool) []model.Patch {
+	var patches []model.Patch
+
+	for _, e := range g.EdgesOfType(graph.Subsumes) {
+		removeIdx := e.To
+		if removed[removeIdx] {
+			continue
+		}
+		removed[removeIdx] = true
+		id := fmt.Sprintf("subsumed-%d", len(patches))
+
+		patches = append(patches, model.Patch{
+			ID:          id,
+			Title:       fmt.Sprintf("Remove subsumed statement %s", m.Ref(removeIdx)),
+			Impact:      fmt.Sprintf("Removes 1 statement already covered by statement %s", m.Ref(e.From)),
+			DiffPreview: removeDiffPreview(p, m, removeIdx),
+			StmtIndices: []int{removeIdx, e.From},
+			Apply:       removeStatement(p.Statement[removeIdx]),
+		})
+	}
+
+	return patches
+}
+
+func removeDead(p *model.Policy, g *graph.Graph, m model.StatementMapping, removed map[int]bool) []model.Patch {
+	var patches []model.Patch
+
+	for _, n := range g.Nodes() {
+		if len(n.ShadowedBy) == 0 || removed[n.Index] {
+			continue
+		}
+		removeIdx := n.Index
+		removed[removeIdx] = true
+		id := fmt.Sprintf("dead-%d", len(patches))
+
+		patches = append(patches, model.Patch{
+			ID:          id,
+			Title:       fmt.Sprintf("Remove dead statement %s", m.Ref(removeIdx)),
+			Impact:      "Removes 1 Allow statement that Deny statements fully override",
+			DiffPreview: removeDiffPreview(p, m, removeIdx),
+			StmtIndices: append([]int{removeIdx}, n.ShadowedBy...),
+			Apply:       removeStatement(p.Statement[removeIdx]),
 		})
 	}
 
 	return patches
 }
 
-func mergeStatements(p *model.Policy, g *graph.Graph) []model.Patch {
+func mergeStatements(p *model.Policy, g *graph.Graph, m model.StatementMapping) []model.Patch {
 	var patches []model.Patch
 	counter := 0
 
@@ -68,16 +139,13 @@ func mergeStatements(p *model.Policy, g *graph.Graph) []model.Patch {
 
 		patches = append(patches, model.Patch{
 			ID:          id,
-			Title:       fmt.Sprintf("Merge actions of statements %d and %d", mergeI, mergeJ),
+			Title:       fmt.Sprintf("Merge actions of statements %s and %s", m.Ref(mergeI), m.Ref(mergeJ)),
 			Impact:      "Combines 2 statements into 1 by merging Actions",
-			DiffPreview: mergeDiffPreview(p, mergeI, mergeJ, "actions"),
-			Apply: func(policy *model.Policy) *model.Policy {
-				cp := deepCopyPolicy(policy)
-				merged := unionStrings(cp.Statement[mergeI].Action, cp.Statement[mergeJ].Action)
-				cp.Statement[mergeI].Action = merged
-				cp.Statement = slices.Delete(cp.Statement, mergeJ, mergeJ+1)
-				return cp
-			},
+			DiffPreview: mergeDiffPreview(m, mergeI, mergeJ, "actions"),
+			StmtIndices: []int{mergeI, mergeJ},
+			Apply: mergeStatement(p.Statement[mergeI], p.Statement[mergeJ], func(into, from *model.Statement) {
+				into.Action = unionStrings(into.Action, from.Action)
+			}),
 		})
 	}
 
@@ -88,22 +156,93 @@ func mergeStatements(p *model.Policy, g *graph.Graph) []model.Patch {
 
 		patches = append(patches, model.Patch{
 			ID:          id,
-			Title:       fmt.Sprintf("Merge resources of statements %d and %d", mergeI, mergeJ),
+			Title:       fmt.Sprintf("Merge resources of statements %s and %s", m.Ref(mergeI), m.Ref(mergeJ)),
 			Impact:      "Combines 2 statements into 1 by merging Resources",
-			DiffPreview: mergeDiffPreview(p, mergeI, mergeJ, "resources"),
-			Apply: func(policy *model.Policy) *model.Policy {
-				cp := deepCopyPolicy(policy)
-				merged := unionStrings(cp.Statement[mergeI].Resource, cp.Statement[mergeJ].Resource)
-				cp.Statement[mergeI].Resource = merged
-				cp.Statement = slices.Delete(cp.Statement, mergeJ, mergeJ+1)
-				return cp
-			},
+			DiffPreview: mergeDiffPreview(m, mergeI, mergeJ, "resources"),
+			StmtIndices: []int{mergeI, mergeJ},
+			Apply: mergeStatement(p.Statement[mergeI], p.Statement[mergeJ], func(into, from *model.Statement) {
+				into.Resource = unionStrings(into.Resource, from.Resource)
+			}),
 		})
 	}
 
 	return patches
 }
 
+func fixActionTypos(p *model.Policy, m model.StatementMapping, cat *catalog.Catalog) []model.Patch {
+	var patches []model.Patch
+	counter := 0
+
+	for i, s := range p.Statement {
+		for _, notAction := range []bool{false, true} {
+			actions := s.Action
+			if notAction {
+				actions = s.NotAction
+			}
+
+			for _, typo := range actions {
+				if strings.ContainsAny(typo, "*?") {
+					continue
+				}
+				if _, known := cat.Lookup(typo); known {
+					continue
+				}
+				match, ok := cat.Suggest(typo)
+				if !ok {
+					continue
+				}
+
+				stmtIdx, from, to, inNotAction := i, typo, match.String(), notAction
+				// Other fixes and merges may rewrite the action lists first, so
+				// the statement is found by the rest of its content.
+				want := fingerprint(withoutActions(s))
+				id := fmt.Sprintf("fix-action-%d", counter)
+				counter++
+
+				patches = append(patches, model.Patch{
+					ID:          id,
+					Title:       fmt.Sprintf("Replace %s with %s in statement %s", from, to, m.Ref(stmtIdx)),
+					Impact:      "Fixes a misspelled action that currently matches nothing",
+					DiffPreview: fmt.Sprintf("- %q\n+ %q", from, to),
+					StmtIndices: []int{stmtIdx},
+					Apply: func(policy *model.Policy) *model.Policy {
+						cp := deepCopyPolicy(policy)
+						for i := range cp.Statement {
+							target := &cp.Statement[i].Action
+							if inNotAction {
+								target = &cp.Statement[i].NotAction
+							}
+							if slices.Contains(*target, from) && fingerprint(withoutActions(cp.Statement[i])) == want {
+								*target = replaceString(*target, from, to)
+								break
+							}
+						}
+						return cp
+					},
+				})
+			}
+		}
+	}
+
+	return patches
+}
+
+func withoutActions(s model.Statement) model.Statement {
+	s.Action, s.NotAction = nil, nil
+	return s
+}
+
+func replaceString(ss []string, from, to string) []string {
+	result := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s == from {
+			s = to
+		}
+		result = append(result, s)
+	}
+	return unionStrings(result, nil)
+}
+
 func unionStrings(a, b []string) []string {
 	seen := make(map[string]bool, len(a)+len(b))
 	var result []string
@@ -123,6 +262,51 @@ func unionStrings(a, b []string) []string {
 	return result
 }
 
+// removeStatement returns an Apply func that deletes target from the policy.
+// Patches are applied in sequence, so earlier removals and merges shift
+// indices; the statement is found by content and left alone once an earlier
+// patch has removed or rewritten it.
+func removeStatement(target model.Statement) func(*model.Policy) *model.Policy {
+	want := fingerprint(target)
+	return func(policy *model.Policy) *model.Policy {
+		cp := deepCopyPolicy(policy)
+		if i := indexOf(cp.Statement, want); i >= 0 {
+			cp.Statement = slices.Delete(cp.Statement, i, i+1)
+		}
+		return cp
+	}
+}
+
+// mergeStatement returns an Apply func that folds from into into and deletes
+// from, locating both by content like removeStatement.
+func mergeStatement(into, from model.Statement, merge func(into, from *model.Statement)) func(*model.Policy) *model.Policy {
+	wantInto, wantFrom := fingerprint(into), fingerprint(from)
+	return func(policy *model.Policy) *model.Policy {
+		cp := deepCopyPolicy(policy)
+		i, j := indexOf(cp.Statement, wantInto), indexOf(cp.Statement, wantFrom)
+		if i < 0 || j < 0 || i == j {
+			return cp
+		}
+		merge(&cp.Statement[i], &cp.Statement[j])
+		cp.Statement = slices.Delete(cp.Statement, j, j+1)
+		return cp
+	}
+}
+
+func indexOf(stmts []model.Statement, want string) int {
+	for i, s := range stmts {
+		if fingerprint(s) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+func fingerprint(s model.Statement) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
 func deepCopyPolicy(p *model.Policy) *model.Policy {
 	data, _ := json.Marshal(p)
 	var cp model.Policy
@@ -130,11 +314,11 @@ func deepCopyPolicy(p *model.Policy) *model.Policy {
 	return &cp
 }
 
-func removeDiffPreview(p *model.Policy, idx int) string {
+func removeDiffPreview(p *model.Policy, m model.StatementMapping, idx int) string {
 	data, _ := json.MarshalIndent(p.Statement[idx], "", "  ")
-	return fmt.Sprintf("- Statement %d:\n- %s", idx, string(data))
+	return fmt.Sprintf("- Statement %s:\n- %s", m.Ref(idx), string(data))
 }
 
-func mergeDiffPreview(p *model.Policy, i, j int, field string) string {
-	return fmt.Sprintf("Merge %s from statement %d into statement %d, remove statement %d", field, j, i, j)
+func mergeDiffPreview(m model.StatementMapping, i, j int, field string) string {
+	return fmt.Sprintf("Merge %s from statement %s into statement %s, remove statement %s", field, m.Ref(j), m.Ref(i), m.Ref(j))
 }
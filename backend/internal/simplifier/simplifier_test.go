@@ -1,6 +1,7 @@
 package simplifier_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Kuba0517/iam-analyzer/internal/model"
@@ -103,6 +104,44 @@ func TestApply_RemoveRedundant(t *testing.T) {
 	}
 }
 
+func TestApply_RemoveSubsumed(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/logs/*"},
+				Condition: model.Condition{"Bool": {"aws:SecureTransport": model.NewConditionValue("true")}}},
+		},
+	}
+
+	patches := simplifier.Suggest(p)
+	if len(patches) != 1 || patches[0].ID != "subsumed-0" {
+		t.Fatalf("expected a single subsumed-0 patch, got %+v", patches)
+	}
+
+	result := simplifier.Apply(p, patches, []string{"subsumed-0"})
+	if len(result.Statement) != 1 || result.Statement[0].Action[0] != "s3:*" {
+		t.Fatalf("expected only the covering statement to remain, got %+v", result.Statement)
+	}
+}
+
+func TestApply_RemoveDead(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:CreateUser"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"iam:*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	patches := simplifier.Suggest(p)
+	result := simplifier.Apply(p, patches, []string{"dead-0"})
+
+	if len(result.Statement) != 1 || result.Statement[0].Effect != "Deny" {
+		t.Fatalf("expected only the Deny statement to remain, got %+v", result.Statement)
+	}
+}
+
 func TestApply_MergeActions(t *testing.T) {
 	p := &model.Policy{
 		Version: "2012-10-17",
@@ -156,3 +195,129 @@ func TestApply_NoSelectedPatches(t *testing.T) {
 		t.Fatalf("expected 2 statements when no patches selected, got %d", len(result.Statement))
 	}
 }
+
+func TestSuggest_FixActionTypo(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObjet", "s3:PutObject"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	patches := simplifier.Suggest(p)
+
+	var fix *model.Patch
+	for i := range patches {
+		if patches[i].ID == "fix-action-0" {
+			fix = &patches[i]
+		}
+	}
+	if fix == nil {
+		t.Fatal("expected a fix-action patch")
+	}
+
+	result := simplifier.Apply(p, patches, []string{"fix-action-0"})
+	actions := result.Statement[0].Action
+	if len(actions) != 2 || actions[0] != "s3:GetObject" || actions[1] != "s3:PutObject" {
+		t.Errorf("expected [s3:GetObject s3:PutObject], got %v", actions)
+	}
+	if p.Statement[0].Action[0] != "s3:GetObjet" {
+		t.Error("apply must not modify the input policy")
+	}
+}
+
+func TestSuggestWithMapping_OriginalIndices(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+	mapping := model.StatementMapping{{Normalized: 0, Original: 2}, {Normalized: 1, Original: 0, Sid: "Copy"}}
+
+	patches := simplifier.SuggestWithMapping(p, mapping)
+	for _, patch := range patches {
+		if patch.ID != "dedup-0" {
+			continue
+		}
+		if len(patch.StmtIndices) != 1 || patch.StmtIndices[0] != 1 || patch.OriginalIndices[0] != 0 {
+			t.Errorf("expected normalized 1 and original 0, got %v and %v", patch.StmtIndices, patch.OriginalIndices)
+		}
+		if patch.Title != "Remove redundant statement 0 (Copy)" {
+			t.Errorf("unexpected title %q", patch.Title)
+		}
+		return
+	}
+	t.Fatal("expected a dedup patch")
+}
+
+func TestApply_AllPatches(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:*"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"arn:aws:s3:::b/*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:CreateUser"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"iam:DeleteUser"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Deny", Action: model.StringOrSlice{"iam:Delete*"}, Resource: model.StringOrSlice{"*"}},
+		},
+	}
+
+	patches := simplifier.Suggest(p)
+	seen := make(map[int]bool)
+	var ids []string
+	for _, patch := range patches {
+		ids = append(ids, patch.ID)
+		if strings.HasPrefix(patch.ID, "merge-") {
+			continue
+		}
+		if seen[patch.StmtIndices[0]] {
+			t.Errorf("statement %d is removed by more than one patch", patch.StmtIndices[0])
+		}
+		seen[patch.StmtIndices[0]] = true
+	}
+
+	result := simplifier.Apply(p, patches, ids)
+	var got []string
+	for _, s := range result.Statement {
+		got = append(got, s.Effect+" "+s.Action[0])
+	}
+	want := []string{"Allow s3:*", "Allow iam:CreateUser", "Deny iam:Delete*"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestApply_FixActionTyposAfterRemoval(t *testing.T) {
+	p := &model.Policy{
+		Version: "2012-10-17",
+		Statement: []model.Statement{
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"s3:GetObject"}, Resource: model.StringOrSlice{"*"}},
+			{Effect: "Allow", Action: model.StringOrSlice{"ec2:DescribeInstancez", "ec2:StartInstancez"}, Resource: model.StringOrSlice{"arn:aws:ec2:*:*:instance/*"}},
+		},
+	}
+
+	patches := simplifier.Suggest(p)
+	var ids []string
+	for _, patch := range patches {
+		ids = append(ids, patch.ID)
+	}
+
+	result := simplifier.Apply(p, patches, ids)
+	if len(result.Statement) != 2 {
+		t.Fatalf("expected 2 statements, got %+v", result.Statement)
+	}
+	actions := result.Statement[1].Action
+	if len(actions) != 2 || actions[0] != "ec2:DescribeInstances" || actions[1] != "ec2:StartInstances" {
+		t.Errorf("expected both typos fixed, got %v", actions)
+	}
+}
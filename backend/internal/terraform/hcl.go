@@ -0,0 +1,228 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/Kuba0517/iam-analyzer/internal/model"
+)
+
+var ErrInvalidHCL = errors.New("invalid HCL")
+
+// ConvertHCL turns every data "aws_iam_policy_document" block of a
+// configuration file into a policy. Expressions that need variables, locals
+// or other resources to evaluate are kept as ${...} placeholders.
+func ConvertHCL(src []byte, filename string) ([]Document, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHCL, diags.Error())
+	}
+	body, _ := file.Body.(*hclsyntax.Body)
+	if body == nil {
+		return nil, nil
+	}
+
+	c := &converter{src: src}
+	var docs []Document
+	for _, block := range body.Blocks {
+		if block.Type != "data" || len(block.Labels) != 2 || block.Labels[0] != "aws_iam_policy_document" {
+			continue
+		}
+		data, err := json.Marshal(c.policy(block.Body))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, Document{
+			Address:   "data.aws_iam_policy_document." + block.Labels[1],
+			Type:      "aws_iam_policy_document",
+			Attribute: "json",
+			Policy:    data,
+			File:      filename,
+			Line:      block.DefRange().Start.Line,
+		})
+	}
+	return docs, nil
+}
+
+type converter struct {
+	src []byte
+}
+
+func (c *converter) policy(body *hclsyntax.Body) *model.Policy {
+	p := &model.Policy{Version: "2012-10-17", Statement: []model.Statement{}}
+	if attr, ok := body.Attributes["version"]; ok {
+		p.Version = c.str(attr.Expr)
+	}
+	if attr, ok := body.Attributes["policy_id"]; ok {
+		p.Id = c.str(attr.Expr)
+	}
+	for _, block := range body.Blocks {
+		if block.Type == "statement" {
+			p.Statement = append(p.Statement, c.statement(block.Body))
+		}
+	}
+	return p
+}
+
+func (c *converter) statement(body *hclsyntax.Body) model.Statement {
+	stmt := model.Statement{Effect: "Allow"}
+	for name, attr := range body.Attributes {
+		switch name {
+		case "sid":
+			stmt.Sid = c.str(attr.Expr)
+		case "effect":
+			stmt.Effect = c.str(attr.Expr)
+		case "actions":
+			stmt.Action = c.strings(attr.Expr)
+		case "not_actions":
+			stmt.NotAction = c.strings(attr.Expr)
+		case "resources":
+			stmt.Resource = c.strings(attr.Expr)
+		case "not_resources":
+			stmt.NotResource = c.strings(attr.Expr)
+		}
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "principals":
+			stmt.Principal = c.principal(stmt.Principal, block.Body)
+		case "not_principals":
+			stmt.NotPrincipal = c.principal(stmt.NotPrincipal, block.Body)
+		case "condition":
+			c.condition(&stmt, block.Body)
+		}
+	}
+	return stmt
+}
+
+// principal adds a principals block to p. Type "*" is how Terraform writes
+// Principal: "*".
+func (c *converter) principal(p *model.Principal, body *hclsyntax.Body) *model.Principal {
+	if p == nil {
+		p = &model.Principal{}
+	}
+	var typ string
+	var ids []string
+	if attr, ok := body.Attributes["type"]; ok {
+		typ = c.str(attr.Expr)
+	}
+	if attr, ok := body.Attributes["identifiers"]; ok {
+		ids = c.strings(attr.Expr)
+	}
+
+	if typ == "*" {
+		p.Wildcard = true
+		return p
+	}
+	if p.Members == nil {
+		p.Members = make(map[string][]string)
+	}
+	p.Members[typ] = append(p.Members[typ], ids...)
+	return p
+}
+
+func (c *converter) condition(stmt *model.Statement, body *hclsyntax.Body) {
+	var test, variable string
+	var values []string
+	if attr, ok := body.Attributes["test"]; ok {
+		test = c.str(attr.Expr)
+	}
+	if attr, ok := body.Attributes["variable"]; ok {
+		variable = c.str(attr.Expr)
+	}
+	if attr, ok := body.Attributes["values"]; ok {
+		values = c.strings(attr.Expr)
+	}
+
+	if stmt.Condition == nil {
+		stmt.Condition = make(model.Condition)
+	}
+	if stmt.Condition[test] == nil {
+		stmt.Condition[test] = make(map[string]model.ConditionValue)
+	}
+	cv := stmt.Condition[test][variable]
+	for _, v := range values {
+		cv.Values = append(cv.Values, v)
+	}
+	stmt.Condition[test][variable] = cv
+}
+
+// str evaluates a single string, falling back to a placeholder.
+func (c *converter) str(expr hclsyntax.Expression) string {
+	if v, ok := c.value(expr); ok && v.Type().IsPrimitiveType() {
+		if s, err := convert.Convert(v, cty.String); err == nil {
+			return s.AsString()
+		}
+	}
+	return c.symbolic(expr)
+}
+
+// strings evaluates a list of strings. A list that cannot be evaluated as a
+// whole is evaluated item by item.
+func (c *converter) strings(expr hclsyntax.Expression) []string {
+	if v, ok := c.value(expr); ok {
+		if v.Type().IsPrimitiveType() {
+			return []string{c.str(expr)}
+		}
+		if v.CanIterateElements() {
+			var out []string
+			for it := v.ElementIterator(); it.Next(); {
+				_, item := it.Element()
+				if s, err := convert.Convert(item, cty.String); err == nil && s.IsKnown() && !s.IsNull() {
+					out = append(out, s.AsString())
+				}
+			}
+			return out
+		}
+	}
+
+	if tuple, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+		out := make([]string, len(tuple.Exprs))
+		for i, item := range tuple.Exprs {
+			out[i] = c.str(item)
+		}
+		return out
+	}
+	return []string{c.symbolic(expr)}
+}
+
+func (c *converter) value(expr hclsyntax.Expression) (cty.Value, bool) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+		return cty.NilVal, false
+	}
+	return v, true
+}
+
+// symbolic writes an expression as text, keeping the literal parts of string
+// templates and wrapping everything else in ${...}.
+func (c *converter) symbolic(expr hclsyntax.Expression) string {
+	if tmpl, ok := expr.(*hclsyntax.TemplateExpr); ok {
+		var b strings.Builder
+		for _, part := range tmpl.Parts {
+			if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				b.WriteString(lit.Val.AsString())
+				continue
+			}
+			b.WriteString(c.symbolic(part))
+		}
+		return b.String()
+	}
+	if wrap, ok := expr.(*hclsyntax.TemplateWrapExpr); ok {
+		return c.symbolic(wrap.Wrapped)
+	}
+
+	rng := expr.Range()
+	if rng.Start.Byte < 0 || rng.End.Byte > len(c.src) || rng.Start.Byte >= rng.End.Byte {
+		return "${?}"
+	}
+	return "${" + string(c.src[rng.Start.Byte:rng.End.Byte]) + "}"
+}
@@ -0,0 +1,135 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPlan = errors.New("invalid Terraform plan")
+
+// Document is one policy document found in Terraform input, keyed by the
+// address of the resource or data source it belongs to. File and Line are
+// only known for HCL.
+type Document struct {
+	Address   string
+	Type      string
+	Attribute string
+	Name      string
+	Policy    []byte
+	File      string
+	Line      int
+}
+
+// policyAttributes lists the attributes holding a policy document as a JSON
+// string for each resource type.
+var policyAttributes = map[string][]string{
+	"aws_iam_policy":            {"policy"},
+	"aws_iam_role_policy":       {"policy"},
+	"aws_iam_user_policy":       {"policy"},
+	"aws_iam_group_policy":      {"policy"},
+	"aws_iam_role":              {"assume_role_policy"},
+	"aws_s3_bucket_policy":      {"policy"},
+	"aws_sqs_queue_policy":      {"policy"},
+	"aws_sns_topic_policy":      {"policy"},
+	"aws_kms_key":               {"policy"},
+	"aws_ecr_repository_policy": {"policy"},
+	"aws_iam_policy_document":   {"json"},
+}
+
+type plan struct {
+	FormatVersion string  `json:"format_version"`
+	PlannedValues *values `json:"planned_values"`
+	Values        *values `json:"values"`
+}
+
+type values struct {
+	RootModule module `json:"root_module"`
+}
+
+type module struct {
+	Resources    []resource `json:"resources"`
+	ChildModules []module   `json:"child_modules"`
+}
+
+type resource struct {
+	Address string         `json:"address"`
+	Type    string         `json:"type"`
+	Values  map[string]any `json:"values"`
+}
+
+// IsPlan reports whether raw is the output of terraform show -json, for a
+// plan or for state.
+func IsPlan(raw []byte) bool {
+	var p plan
+	if json.Unmarshal(raw, &p) != nil {
+		return false
+	}
+	return p.FormatVersion != "" && (p.PlannedValues != nil || p.Values != nil)
+}
+
+// Extract reads policies from plan JSON or, for anything that is not a JSON
+// object, from the aws_iam_policy_document data sources of an HCL file.
+// filename is only used in error messages and Document.File.
+func Extract(raw []byte, filename string) ([]Document, error) {
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		return ExtractPlan(raw)
+	}
+	return ConvertHCL(raw, filename)
+}
+
+// ExtractPlan returns the policy documents of every supported resource in
+// plan or state JSON. Policies that are unknown until apply are skipped.
+func ExtractPlan(raw []byte) ([]Document, error) {
+	var p plan
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	v := p.PlannedValues
+	if v == nil {
+		v = p.Values
+	}
+	if p.FormatVersion == "" || v == nil {
+		return nil, fmt.Errorf("%w: expected terraform show -json output", ErrInvalidPlan)
+	}
+
+	var docs []Document
+	walkModule(v.RootModule, func(r resource) {
+		for _, attr := range policyAttributes[r.Type] {
+			if policy, ok := r.Values[attr].(string); ok && policy != "" {
+				docs = append(docs, Document{Address: r.Address, Type: r.Type, Attribute: attr, Policy: []byte(policy)})
+			}
+		}
+
+		if r.Type != "aws_iam_role" {
+			return
+		}
+		inline, _ := r.Values["inline_policy"].([]any)
+		for i, item := range inline {
+			m, _ := item.(map[string]any)
+			policy, _ := m["policy"].(string)
+			if policy == "" {
+				continue
+			}
+			name, _ := m["name"].(string)
+			docs = append(docs, Document{
+				Address:   r.Address,
+				Type:      r.Type,
+				Attribute: fmt.Sprintf("inline_policy[%d].policy", i),
+				Name:      name,
+				Policy:    []byte(policy),
+			})
+		}
+	})
+	return docs, nil
+}
+
+func walkModule(m module, visit func(resource)) {
+	for _, r := range m.Resources {
+		visit(r)
+	}
+	for _, child := range m.ChildModules {
+		walkModule(child, visit)
+	}
+}
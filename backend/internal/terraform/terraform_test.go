@@ -0,0 +1,180 @@
+package terraform_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/parser"
+	"github.com/Kuba0517/iam-analyzer/internal/terraform"
+)
+
+func policyJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExtractPlan(t *testing.T) {
+	admin := policyJSON(t, map[string]any{
+		"Version":   "2012-10-17",
+		"Statement": []any{map[string]any{"Effect": "Allow", "Action": "*", "Resource": "*"}},
+	})
+	trust := policyJSON(t, map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []any{map[string]any{
+			"Effect": "Allow", "Action": "sts:AssumeRole",
+			"Principal": map[string]any{"Service": "lambda.amazonaws.com"},
+		}},
+	})
+	plan := policyJSON(t, map[string]any{
+		"format_version": "1.2",
+		"planned_values": map[string]any{
+			"root_module": map[string]any{
+				"resources": []any{
+					map[string]any{"address": "aws_iam_policy.admin", "type": "aws_iam_policy", "values": map[string]any{"policy": admin}},
+					map[string]any{"address": "aws_iam_policy.pending", "type": "aws_iam_policy", "values": map[string]any{"policy": nil}},
+					map[string]any{"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "values": map[string]any{"bucket": "logs"}},
+				},
+				"child_modules": []any{map[string]any{
+					"address": "module.app",
+					"resources": []any{map[string]any{
+						"address": "module.app.aws_iam_role.this",
+						"type":    "aws_iam_role",
+						"values": map[string]any{
+							"assume_role_policy": trust,
+							"inline_policy":      []any{map[string]any{"name": "inline", "policy": admin}},
+						},
+					}},
+				}},
+			},
+		},
+	})
+
+	if !terraform.IsPlan([]byte(plan)) {
+		t.Fatal("expected the plan to be recognized")
+	}
+	docs, err := terraform.Extract([]byte(plan), "plan.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, d := range docs {
+		got = append(got, d.Address+" "+d.Attribute)
+		if _, err := parser.Parse(d.Policy); err != nil {
+			t.Errorf("%s does not parse: %v", d.Address, err)
+		}
+	}
+	want := []string{
+		"aws_iam_policy.admin policy",
+		"module.app.aws_iam_role.this assume_role_policy",
+		"module.app.aws_iam_role.this inline_policy[0].policy",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if docs[2].Name != "inline" {
+		t.Errorf("unexpected inline policy name %q", docs[2].Name)
+	}
+}
+
+func TestExtractPlan_NotPlan(t *testing.T) {
+	raw := []byte(`{"Version": "2012-10-17", "Statement": []}`)
+	if terraform.IsPlan(raw) {
+		t.Error("a policy is not a plan")
+	}
+	if _, err := terraform.Extract(raw, "policy.json"); !errors.Is(err, terraform.ErrInvalidPlan) {
+		t.Errorf("expected ErrInvalidPlan, got %v", err)
+	}
+}
+
+const config = `variable "bucket" {}
+
+data "aws_iam_policy_document" "read" {
+  policy_id = "read"
+
+  statement {
+    sid       = "Read"
+    actions   = ["s3:GetObject", "s3:ListBucket"]
+    resources = ["arn:aws:s3:::${var.bucket}/*", aws_s3_bucket.logs.arn]
+
+    principals {
+      type        = "AWS"
+      identifiers = ["arn:aws:iam::123456789012:root"]
+    }
+
+    condition {
+      test     = "Bool"
+      variable = "aws:SecureTransport"
+      values   = [true]
+    }
+  }
+
+  statement {
+    effect      = "Deny"
+    not_actions = ["s3:*"]
+    resources   = ["*"]
+
+    principals {
+      type        = "*"
+      identifiers = ["*"]
+    }
+  }
+}
+
+resource "aws_iam_policy" "read" {
+  policy = data.aws_iam_policy_document.read.json
+}
+`
+
+func TestConvertHCL(t *testing.T) {
+	docs, err := terraform.ConvertHCL([]byte(config), "main.tf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected one document, got %d", len(docs))
+	}
+	d := docs[0]
+	if d.Address != "data.aws_iam_policy_document.read" || d.File != "main.tf" || d.Line != 3 {
+		t.Errorf("unexpected document %+v", d)
+	}
+
+	p, err := parser.Parse(d.Policy)
+	if err != nil {
+		t.Fatalf("converted policy does not parse: %v\n%s", err, d.Policy)
+	}
+	if p.Version != "2012-10-17" || p.Id != "read" || len(p.Statement) != 2 {
+		t.Fatalf("unexpected policy %s", d.Policy)
+	}
+
+	read := p.Statement[0]
+	if read.Sid != "Read" || read.Effect != "Allow" || len(read.Action) != 2 {
+		t.Errorf("unexpected statement %+v", read)
+	}
+	if read.Resource[0] != "arn:aws:s3:::${var.bucket}/*" || read.Resource[1] != "${aws_s3_bucket.logs.arn}" {
+		t.Errorf("unexpected resources %v", read.Resource)
+	}
+	if got := read.Principal.Members["AWS"]; len(got) != 1 || got[0] != "arn:aws:iam::123456789012:root" {
+		t.Errorf("unexpected principal %+v", read.Principal)
+	}
+	if got := read.Condition["Bool"]["aws:SecureTransport"].Strings(); len(got) != 1 || got[0] != "true" {
+		t.Errorf("unexpected condition %v", read.Condition)
+	}
+
+	deny := p.Statement[1]
+	if deny.Effect != "Deny" || deny.NotAction[0] != "s3:*" || !deny.Principal.Wildcard {
+		t.Errorf("unexpected statement %+v", deny)
+	}
+}
+
+func TestConvertHCL_Invalid(t *testing.T) {
+	if _, err := terraform.ConvertHCL([]byte(`data "aws_iam_policy_document" {`), "main.tf"); !errors.Is(err, terraform.ErrInvalidHCL) {
+		t.Errorf("expected ErrInvalidHCL, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package textdist_test
+
+import (
+	"testing"
+
+	"github.com/Kuba0517/iam-analyzer/internal/textdist"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"effect", "effect", 0},
+		{"efect", "effect", 1},
+		{"resources", "resource", 1},
+		{"getobjcet", "getobject", 2},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := textdist.Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := textdist.Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
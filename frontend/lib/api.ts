@@ -7,8 +7,10 @@ export interface Principal {
   Members: Record<string, string[]>;
 }
 
+export type ConditionScalar = string | number | boolean;
+
 export interface Condition {
-  [operator: string]: { [key: string]: string[] };
+  [operator: string]: { [key: string]: ConditionScalar | ConditionScalar[] };
 }
 
 export interface Statement {
@@ -33,6 +35,8 @@ export interface ScoreBreakdown {
   label: string;
   value: string;
   score: number;
+  statementIndices?: number[];
+  originalStatementIndices?: number[];
 }
 
 export interface ScoreResult {
@@ -41,12 +45,37 @@ export interface ScoreResult {
   breakdown: ScoreBreakdown[];
 }
 
+export interface SourcePosition {
+  offset: number;
+  line: number;
+  column: number;
+}
+
+export interface SourceRange {
+  statement: number;
+  start: SourcePosition;
+  end: SourcePosition;
+}
+
+export interface ValidationIssue {
+  level: "error" | "warning";
+  code: string;
+  message: string;
+  // -1 for problems with the document itself
+  statement: number;
+  field?: string;
+  position?: SourcePosition;
+}
+
 export interface Finding {
+  ruleId?: string;
   severity: "low" | "medium" | "high";
   title: string;
   explanation: string;
   evidence: string;
   statementIndices: number[];
+  originalStatementIndices?: number[];
+  locations?: SourceRange[];
 }
 
 export interface Patch {
@@ -54,12 +83,17 @@ export interface Patch {
   title: string;
   impact: string;
   diffPreview: string;
+  statementIndices?: number[];
+  originalStatementIndices?: number[];
 }
 
 export interface GraphNode {
   index: number;
+  originalIndex: number;
+  sid?: string;
   label: string;
   effect: string;
+  shadowedBy?: number[];
 }
 
 export interface GraphEdge {
@@ -74,6 +108,12 @@ export interface GraphData {
   edges: GraphEdge[];
 }
 
+export interface StatementRef {
+  normalized: number;
+  original: number;
+  sid?: string;
+}
+
 export interface AnalyzeResponse {
   original: Policy;
   normalized: Policy;
@@ -81,21 +121,27 @@ export interface AnalyzeResponse {
   findings: Finding[];
   suggestions: Patch[];
   graph?: GraphData;
+  mapping: StatementRef[];
+  warnings?: ValidationIssue[];
 }
 
 export interface ApplyResponse {
   simplified: Policy;
+  simplifiedYaml?: string;
   score: ScoreResult;
   findings: Finding[];
   graph?: GraphData;
 }
 
 export async function analyzePolicy(
-  policyJson: string
+  policyJson: string,
+  strict = false
 ): Promise<AnalyzeResponse> {
-  const res = await fetch(`${API_BASE}/analyze`, {
+  const query = strict ? "?strict=true" : "";
+  const isJson = /^\s*[{[]/.test(policyJson);
+  const res = await fetch(`${API_BASE}/analyze${query}`, {
     method: "POST",
-    headers: { "Content-Type": "application/json" },
+    headers: { "Content-Type": isJson ? "application/json" : "application/yaml" },
     body: policyJson,
   });
   if (!res.ok) {
@@ -112,14 +158,137 @@ export async function analyzePolicy(
   return res.json();
 }
 
+export interface TemplatePolicy {
+  logicalId: string;
+  resourceType: string;
+  path: string;
+  policyName?: string;
+  error?: string;
+  issues?: ValidationIssue[];
+  analysis?: AnalyzeResponse;
+}
+
+export interface TemplateResponse {
+  policies: TemplatePolicy[];
+}
+
+export async function analyzeTemplate(
+  template: string
+): Promise<TemplateResponse> {
+  const res = await fetch(`${API_BASE}/analyze/cloudformation`, {
+    method: "POST",
+    headers: { "Content-Type": "text/plain" },
+    body: template,
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    let message = "Template analysis failed";
+    try {
+      const err = JSON.parse(text);
+      message = err.error || message;
+    } catch {
+      message = text || `Server error: ${res.status}`;
+    }
+    throw new Error(message);
+  }
+  return res.json();
+}
+
+export interface TerraformPolicy {
+  address: string;
+  resourceType: string;
+  attribute: string;
+  policyName?: string;
+  file?: string;
+  line?: number;
+  error?: string;
+  issues?: ValidationIssue[];
+  analysis?: AnalyzeResponse;
+}
+
+export interface TerraformResponse {
+  policies: TerraformPolicy[];
+}
+
+export async function analyzeTerraform(
+  source: string,
+  filename = "main.tf"
+): Promise<TerraformResponse> {
+  const params = new URLSearchParams({ filename });
+  const res = await fetch(`${API_BASE}/analyze/terraform?${params}`, {
+    method: "POST",
+    headers: { "Content-Type": "text/plain" },
+    body: source,
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    let message = "Terraform analysis failed";
+    try {
+      const err = JSON.parse(text);
+      message = err.error || message;
+    } catch {
+      message = text || `Server error: ${res.status}`;
+    }
+    throw new Error(message);
+  }
+  return res.json();
+}
+
+export interface AccountPolicy {
+  name: string;
+  arn?: string;
+  kind: "inline" | "managed";
+  via?: string;
+  error?: string;
+  issues?: ValidationIssue[];
+  analysis?: AnalyzeResponse;
+}
+
+export interface AccountEntity {
+  type: "user" | "group" | "role" | "policy";
+  name: string;
+  arn: string;
+  policies: AccountPolicy[];
+  statements?: { policy: number; statement: number }[];
+  missingPolicies?: string[];
+  analysis?: AnalyzeResponse;
+  trust?: AnalyzeResponse;
+  trustError?: string;
+}
+
+export interface AccountResponse {
+  entities: AccountEntity[];
+}
+
+export async function analyzeAccount(dump: string): Promise<AccountResponse> {
+  const res = await fetch(`${API_BASE}/analyze/account`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: dump,
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    let message = "Account analysis failed";
+    try {
+      const err = JSON.parse(text);
+      message = err.error || message;
+    } catch {
+      message = text || `Server error: ${res.status}`;
+    }
+    throw new Error(message);
+  }
+  return res.json();
+}
+
 export async function applyPatches(
   policy: Policy,
-  patchIds: string[]
+  patchIds: string[],
+  format: "json" | "yaml" = "json"
 ): Promise<ApplyResponse> {
   const res = await fetch(`${API_BASE}/apply`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ policy, patchIds }),
+    body: JSON.stringify({ policy, patchIds, format }),
   });
   if (!res.ok) {
     const text = await res.text();